@@ -0,0 +1,58 @@
+package failtrace
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveHalfLife is the time after which an outcome counts half as
+	// much in the recent error ratio.
+	adaptiveHalfLife = time.Minute
+	// adaptiveFloor is the keep-rate of successful traces when no request
+	// failed recently.
+	adaptiveFloor = 0.01
+)
+
+// ratioTracker tracks the recent ratio of failed requests, with outcomes
+// decaying exponentially over time.
+type ratioTracker struct {
+	mu     sync.Mutex
+	failed float64
+	total  float64
+	last   time.Time
+}
+
+// errorRatio is the process-wide tracker consulted by WithAdaptiveSampling.
+var errorRatio ratioTracker
+
+// observe records the outcome of a request flushed at t and returns the
+// updated error ratio.
+func (r *ratioTracker) observe(failed bool, t time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.last.IsZero() && t.After(r.last) {
+		decay := math.Exp2(-float64(t.Sub(r.last)) / float64(adaptiveHalfLife))
+		r.failed *= decay
+		r.total *= decay
+	}
+	r.last = t
+	r.total++
+	if failed {
+		r.failed++
+	}
+	return r.failed / r.total
+}
+
+// WithAdaptiveSampling keeps the trace of a successful request (FlushIf(nil))
+// with a probability that follows the recent error ratio of all loggers using
+// this option, so more healthy traces are kept for comparison while errors are
+// frequent. The keep-rate never drops below 1%. Error flushes are always
+// written.
+func WithAdaptiveSampling() Option {
+	return func(l *requestLogger) {
+		l.adaptive = true
+	}
+}
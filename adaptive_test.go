@@ -0,0 +1,53 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestWithAdaptiveSampling(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sampleFloat = r.Float64
+	defer func() { sampleFloat = rand.Float64 }()
+	errorRatio = ratioTracker{}
+	defer func() { errorRatio = ratioTracker{} }()
+
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	flush := func(err error) bool {
+		var buf bytes.Buffer
+		logger := New(&buf, WithClock(clock), WithAdaptiveSampling())
+		logger.Info("info message")
+		logger.FlushIf(err)
+		clock.t = clock.t.Add(100 * time.Millisecond)
+		return buf.Len() > 0
+	}
+	kept := func(n int) int {
+		count := 0
+		for i := 0; i < n; i++ {
+			if flush(nil) {
+				count++
+			}
+		}
+		return count
+	}
+
+	calm := kept(200)
+	for i := 0; i < 100; i++ {
+		if !flush(errors.New("test error")) {
+			t.Fatal("Expected error flushes to always be written")
+		}
+	}
+	burst := kept(200)
+
+	if calm > 10 {
+		t.Errorf("Expected few successful traces kept without errors, got %d of 200", calm)
+	}
+	if burst < 4*calm || burst < 40 {
+		t.Errorf("Expected the keep-rate to rise after a burst of errors, got %d of 200 (was %d)", burst, calm)
+	}
+}
@@ -0,0 +1,96 @@
+package failtrace
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// errWriterClosed is returned by writes to a closed async writer.
+var errWriterClosed = errors.New("failtrace: write to closed async writer")
+
+// asyncWriter queues writes on a channel drained by a background goroutine.
+type asyncWriter struct {
+	dst  io.Writer
+	drop bool
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+	err    error
+}
+
+// NewAsyncWriter returns a writer queuing up to queue writes for a background
+// goroutine writing them to dst in order, so flushes do not wait for slow I/O.
+// When the queue is full, writes block until there is room; see
+// NewDroppingAsyncWriter to drop them instead. Close drains the queue, stops
+// the goroutine and returns the first error dst returned, if any.
+func NewAsyncWriter(dst io.Writer, queue int) (io.WriteCloser, error) {
+	return newAsyncWriter(dst, queue, false)
+}
+
+// NewDroppingAsyncWriter behaves like NewAsyncWriter, but drops writes when
+// the queue is full instead of blocking, trading completeness for latency.
+func NewDroppingAsyncWriter(dst io.Writer, queue int) (io.WriteCloser, error) {
+	return newAsyncWriter(dst, queue, true)
+}
+
+func newAsyncWriter(dst io.Writer, queue int, drop bool) (*asyncWriter, error) {
+	if dst == nil {
+		return nil, errors.New("failtrace: async writer needs a destination")
+	}
+	if queue < 1 {
+		return nil, errors.New("failtrace: async writer queue must hold at least one write")
+	}
+	w := &asyncWriter{
+		dst:   dst,
+		drop:  drop,
+		queue: make(chan []byte, queue),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for p := range w.queue {
+		if _, err := w.dst.Write(p); err != nil && w.err == nil {
+			w.err = err
+		}
+	}
+}
+
+// Write queues a copy of p. It reports len(p) even if p is dropped.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, errWriterClosed
+	}
+
+	p = append([]byte(nil), p...)
+	if !w.drop {
+		w.queue <- p
+		return len(p), nil
+	}
+	select {
+	case w.queue <- p:
+	default:
+	}
+	return len(p), nil
+}
+
+// Close writes the queued data to the destination and stops the writer.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+	return w.err
+}
@@ -0,0 +1,44 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewAsyncWriter(t *testing.T) {
+	var dst bytes.Buffer
+	w, err := NewAsyncWriter(&dst, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected strings.Builder
+	for i := 0; i < 100; i++ {
+		logger := New(w)
+		logger.id = fmt.Sprintf("req-%d", i)
+		logger.Debug("debug message")
+		logger.FlushIf(errors.New("test error"))
+		fmt.Fprintf(&expected, "[req-%d] D: debug message\n[req-%d] E: test error\n", i, i)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.String() != expected.String() {
+		t.Errorf("Expected all writes in order, got %q", dst.String())
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("Expected an error writing to a closed writer")
+	}
+}
+
+func TestNewAsyncWriter_InvalidQueue(t *testing.T) {
+	if _, err := NewAsyncWriter(&bytes.Buffer{}, 0); err == nil {
+		t.Error("Expected an error for an empty queue")
+	}
+}
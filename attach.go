@@ -0,0 +1,64 @@
+package failtrace
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// attachment is a named blob written with an error flush.
+type attachment struct {
+	name string
+	data []byte
+}
+
+// Attach records a named blob, such as the offending request body, to be
+// written after the trace when FlushIf flushes an error, framed by a
+// "--- attachment: name (N bytes) ---" line. In JSON mode each attachment is
+// a record of the flush instead:
+//
+//	{"type":"attachment","id":"...","name":"...","bytes":N,"data":"..."}
+//
+// with "data_base64" replacing "data" for blobs that are not valid UTF-8.
+// Attachments are dropped by flushes without an error. data is not copied and
+// must not be modified until the logger is flushed.
+func (l *requestLogger) Attach(name string, data []byte) {
+	o := l.owner()
+	o.attachments = append(o.attachments, attachment{name: name, data: data})
+}
+
+// writeAttachments renders the attachments into b.
+func (l *requestLogger) writeAttachments(b *bytes.Buffer) {
+	eol := l.lineTerminator()
+	for _, a := range l.attachments {
+		fmt.Fprintf(b, "--- attachment: %s (%d bytes) ---%s", a.name, len(a.data), eol)
+		b.Write(a.data)
+		if len(a.data) > 0 && a.data[len(a.data)-1] != '\n' {
+			b.WriteString(eol)
+		}
+	}
+}
+
+// writeJSONAttachments renders the attachments as JSON records into b.
+func (l *requestLogger) writeJSONAttachments(b *bytes.Buffer, id string) {
+	for _, a := range l.attachments {
+		l.beginJSONRecord(b)
+		b.WriteString(`{"type":"attachment","id":`)
+		writeJSONString(b, id)
+		b.WriteString(`,"name":`)
+		writeJSONString(b, a.name)
+		b.WriteString(`,"bytes":`)
+		b.WriteString(strconv.Itoa(len(a.data)))
+		if utf8.Valid(a.data) {
+			b.WriteString(`,"data":`)
+			writeJSONString(b, string(a.data))
+		} else {
+			b.WriteString(`,"data_base64":`)
+			writeJSONString(b, base64.StdEncoding.EncodeToString(a.data))
+		}
+		b.WriteByte('}')
+		l.endJSONRecord(b)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAttach(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.id = "test-123"
+
+	logger.Debug("debug message")
+	logger.Attach("body", []byte(`{"qty":-1}`))
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: debug message\n" +
+		"[test-123] E: test error\n" +
+		"--- attachment: body (10 bytes) ---\n" +
+		"{\"qty\":-1}\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	logger.Debug("debug message")
+	logger.Attach("body", []byte(`{"qty":1}`))
+	logger.Flush()
+
+	if expected := "[test-123] D: debug message\n"; buf.String() != expected {
+		t.Errorf("Expected %q without attachments, got %q", expected, buf.String())
+	}
+	if len(logger.attachments) != 0 {
+		t.Errorf("Expected attachments to be cleared by the flush, got %d", len(logger.attachments))
+	}
+}
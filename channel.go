@@ -0,0 +1,32 @@
+package failtrace
+
+import "sync/atomic"
+
+// channelDrops counts the entries dropped by channel sinks, process-wide.
+var channelDrops atomic.Uint64
+
+// WithChannelSink sends the flushed entries to ch, followed by the error line
+// of FlushIf, instead of writing formatted text. Sends never block: entries
+// that do not fit in the channel are dropped and counted, see ChannelDrops.
+func WithChannelSink(ch chan<- Entry) Option {
+	return func(l *requestLogger) {
+		l.ch = ch
+	}
+}
+
+// ChannelDrops returns how many entries channel sinks have dropped because
+// their channel was full.
+func ChannelDrops() uint64 {
+	return channelDrops.Load()
+}
+
+// send delivers the lead, buffered and trailing entries to the channel sink.
+func (l *requestLogger) send(entries []logEntry, lead, trail []Entry) {
+	for _, e := range l.snapshot(entries, lead, trail...) {
+		select {
+		case l.ch <- e:
+		default:
+			channelDrops.Add(1)
+		}
+	}
+}
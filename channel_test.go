@@ -0,0 +1,61 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithChannelSink(t *testing.T) {
+	ch := make(chan Entry, 8)
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+	}
+	WithChannelSink(ch)(logger)
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+	close(ch)
+
+	expected := []Entry{
+		{Level: DebugLevel, Message: "debug message"},
+		{Level: InfoLevel, Message: "info message"},
+		{Level: ErrorLevel, Message: "test error"},
+	}
+	var got []Entry
+	for e := range ch {
+		got = append(got, e)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(got))
+	}
+	for i, e := range expected {
+		if got[i] != e {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, got[i])
+		}
+	}
+}
+
+func TestWithChannelSink_Full(t *testing.T) {
+	ch := make(chan Entry, 1)
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+	}
+	WithChannelSink(ch)(logger)
+
+	drops := ChannelDrops()
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	if len(ch) != 1 {
+		t.Errorf("Expected 1 entry in the channel, got %d", len(ch))
+	}
+	if n := ChannelDrops() - drops; n != 2 {
+		t.Errorf("Expected 2 dropped entries, got %d", n)
+	}
+}
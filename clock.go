@@ -0,0 +1,27 @@
+package failtrace
+
+import "time"
+
+// Clock tells the time to a logger, for timestamps, step timings, flush
+// durations and rate limiting.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock makes the logger read the time from c instead of the system
+// clock. Unlike replacing a package-level clock, this is safe for tests
+// running in parallel, each with its own fake clock.
+func WithClock(c Clock) Option {
+	return func(l *requestLogger) {
+		l.clock = c
+		l.start = c.Now()
+	}
+}
+
+// now returns the current time from the clock of the owning logger.
+func (l *requestLogger) now() time.Time {
+	if c := l.owner().clock; c != nil {
+		return c.Now()
+	}
+	return now()
+}
@@ -0,0 +1,62 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func TestWithClock(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		clock *fakeClock
+	}{
+		{"first", &fakeClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		{"second", &fakeClock{t: time.Date(2030, 6, 7, 8, 9, 10, 0, time.UTC)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			var got FlushInfo
+			logger := New(&buf, WithClock(tc.clock), WithTimestamps(), OnFlush(func(info FlushInfo) {
+				got = info
+			}))
+			logger.id = "test-123"
+
+			for i := 0; i < 100; i++ {
+				logger.Debug("debug message")
+				logger.Range(func(e Entry) bool {
+					if !e.Time.Equal(tc.clock.t) {
+						t.Errorf("Expected entry time %v, got %v", tc.clock.t, e.Time)
+					}
+					return true
+				})
+				logger.Flush()
+			}
+
+			start := tc.clock.t
+			tc.clock.t = start.Add(time.Second)
+			logger.FlushIf(errors.New("test error"))
+
+			if got.Duration != time.Second {
+				t.Errorf("Expected duration 1s, got %v", got.Duration)
+			}
+			expected := "[test-123] " + tc.clock.t.Format(time.RFC3339Nano) + " E: test error\n"
+			if !bytes.HasSuffix(buf.Bytes(), []byte(expected)) {
+				t.Errorf("Expected output ending with %q, got %q", expected, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package failtrace
+
+import (
+	"io"
+	"maps"
+	"os"
+)
+
+type colorMode byte
+
+const (
+	colorOff colorMode = iota
+	colorAuto
+	colorForced
+)
+
+const colorReset = "\x1b[0m"
+
+// defaultTheme colors errors and fatal entries red and warnings yellow.
+var defaultTheme = map[Level]string{
+	WarnLevel:  "\x1b[33m",
+	ErrorLevel: "\x1b[31m",
+	FatalLevel: "\x1b[31m",
+}
+
+// WithColor colors the level of flushed lines when the writer is a terminal:
+// errors in red and warnings in yellow. Other writers receive plain text.
+func WithColor() Option {
+	return func(l *requestLogger) {
+		l.color = colorAuto
+	}
+}
+
+// WithForcedColor colors the level of flushed lines like WithColor, even if
+// the writer is not a terminal.
+func WithForcedColor() Option {
+	return func(l *requestLogger) {
+		l.color = colorForced
+	}
+}
+
+// WithColorTheme colors the level of flushed lines with the given ANSI escape
+// sequences instead of the default red and yellow, e.g. "\x1b[35m" for
+// magenta; a reset is appended after the level. Levels missing from theme are
+// not colored. It enables color like WithColor unless WithForcedColor is used.
+func WithColorTheme(theme map[Level]string) Option {
+	theme = maps.Clone(theme)
+	return func(l *requestLogger) {
+		l.palette = theme
+		if l.color == colorOff {
+			l.color = colorAuto
+		}
+	}
+}
+
+// IsTerminal reports whether w is a file referring to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// theme returns the color theme to render with, or nil for plain text.
+func (l *requestLogger) theme() map[Level]string {
+	palette := defaultTheme
+	if l.palette != nil {
+		palette = l.palette
+	}
+	switch l.color {
+	case colorForced:
+		return palette
+	case colorAuto:
+		if IsTerminal(l.w) {
+			return palette
+		}
+	}
+	return nil
+}
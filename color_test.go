@@ -0,0 +1,79 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithForcedColor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithForcedColor()(logger)
+
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] I: info message\n" +
+		"[test-123] \x1b[33mW\x1b[0m: warn message\n" +
+		"[test-123] \x1b[31mE\x1b[0m: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithColor_NonTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithColor()(logger)
+
+	logger.Warn("warn message")
+	logger.FlushIf(errors.New("test error"))
+
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("Expected plain text for non-terminal writer, got %q", buf.String())
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Error("Expected bytes.Buffer not to be a terminal")
+	}
+}
+
+func TestWithColorTheme(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithForcedColor()(logger)
+	WithColorTheme(map[Level]string{
+		DebugLevel: "\x1b[90m",
+		ErrorLevel: "\x1b[35m",
+	})(logger)
+
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] \x1b[90mD\x1b[0m: debug message\n" +
+		"[test-123] W: warn message\n" +
+		"[test-123] \x1b[35mE\x1b[0m: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,66 @@
+package failtrace
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+)
+
+// FlushCompactIf behaves like FlushIf, but writes the trace as a single line
+// summarizing it, for dashboards with room for one line per request: the
+// number of entries per level, the last buffered message and the error.
+//
+//	[id] D3 I1 W1 last="op failed" err="boom"
+func (l *requestLogger) FlushCompactIf(err error) {
+	o := l.owner()
+	if l.inherited || o.flushed {
+		return
+	}
+	defer o.put()
+
+	if err != nil && o.shouldFlush != nil && !o.shouldFlush(err) {
+		err = nil
+	}
+	o.notify(err)
+	if err == nil {
+		return
+	}
+
+	var counts [256]int
+	var present []Level
+	for _, e := range o.buf {
+		if counts[e.level] == 0 {
+			present = append(present, e.level)
+		}
+		counts[e.level]++
+	}
+	slices.SortStableFunc(present, func(a, b Level) int {
+		return a.rank() - b.rank()
+	})
+
+	b := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		b.Reset()
+		bufPool.Put(b)
+	}()
+
+	b.WriteString(o.prefix)
+	b.WriteByte('[')
+	b.WriteString(o.host)
+	b.WriteString(o.ID())
+	b.WriteByte(']')
+	for _, level := range present {
+		b.WriteByte(' ')
+		b.WriteByte(byte(level))
+		b.WriteString(strconv.Itoa(counts[level]))
+	}
+	if n := len(o.buf); n > 0 {
+		b.WriteString(" last=")
+		b.WriteString(strconv.Quote(o.buf[n-1].message))
+	}
+	b.WriteString(" err=")
+	b.WriteString(strconv.Quote(err.Error()))
+	b.WriteString(o.lineTerminator())
+
+	writeBuffer(o.w, b)
+}
@@ -0,0 +1,47 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFlushCompactIf(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Warn("retrying")
+	for i := 0; i < 3; i++ {
+		logger.Debugf("step %d", i)
+	}
+	logger.Info("calling upstream")
+	logger.Error(`op "charge" failed`)
+	logger.FlushCompactIf(errors.New("boom"))
+
+	expected := `[test-123] D3 I1 W1 E1 last="op \"charge\" failed" err="boom"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestFlushCompactIf_NoError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Debug("debug message")
+	logger.FlushCompactIf(nil)
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,75 @@
+package failtrace
+
+import (
+	"sync"
+	"time"
+)
+
+// cooldown remembers when each request ID was last flushed, shared by all
+// loggers created with the same WithFlushCooldown option.
+type cooldown struct {
+	mu     sync.Mutex
+	window time.Duration
+	last   map[string]time.Time
+	// order lists the windows in the order they started, so expired ones
+	// are pruned from its front.
+	order []flushWindow
+}
+
+// flushWindow is the start of a cooldown window for an ID.
+type flushWindow struct {
+	id    string
+	start time.Time
+}
+
+// WithFlushCooldown coalesces flushes of the same request ID within d of the
+// first one: later flushes in the window write nothing. It catches a trace
+// flushed twice in quick succession, e.g. by a retry wrapper re-entering the
+// handler with the same standalone logger, beyond what the double-flush
+// sentinel of pooled loggers covers. Only standalone and detached loggers,
+// whose ID outlives a flush, are checked; pooled loggers get a fresh ID per
+// request and are left alone. Time is read from the logger's clock, see
+// WithClock.
+//
+// The state lives in the returned option, so create it once and pass it to
+// every logger that may share IDs:
+//
+//	cooldown := failtrace.WithFlushCooldown(time.Second)
+//	log := failtrace.New(os.Stderr, cooldown)
+func WithFlushCooldown(d time.Duration) Option {
+	c := &cooldown{window: d, last: make(map[string]time.Time)}
+	return func(l *requestLogger) {
+		l.cooldown = c
+	}
+}
+
+// allow reports whether a flush of id at time t may be written, and if so
+// starts a new window for id. Expired windows are pruned first, oldest
+// first, so the cost is amortized over the flushes.
+func (c *cooldown) allow(id string, t time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for ; n < len(c.order) && t.Sub(c.order[n].start) >= c.window; n++ {
+		w := c.order[n]
+		if c.last[w.id].Equal(w.start) {
+			delete(c.last, w.id)
+		}
+	}
+	clear(c.order[:n])
+	c.order = c.order[n:]
+
+	if _, ok := c.last[id]; ok {
+		return false
+	}
+	c.last[id] = t
+	c.order = append(c.order, flushWindow{id: id, start: t})
+	return true
+}
+
+// coolingDown reports whether a flush of l falls in the window of a previous
+// flush of its ID, see WithFlushCooldown.
+func (l *requestLogger) coolingDown() bool {
+	return l.cooldown != nil && l.detached && !l.cooldown.allow(l.ID(), l.now())
+}
@@ -0,0 +1,71 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countingWriter counts the Write calls it receives.
+type countingWriter struct {
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), nil
+}
+
+func TestWithFlushCooldown(t *testing.T) {
+	w := &countingWriter{}
+	clock := &fakeClock{t: time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)}
+	logger := New(w, WithClock(clock), WithFlushCooldown(time.Second))
+
+	logger.Info("attempt one")
+	logger.FlushIf(errors.New("test error"))
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	logger.Info("attempt two")
+	logger.FlushIf(errors.New("test error"))
+
+	if w.writes != 1 {
+		t.Errorf("Expected 1 write within the cooldown, got %d", w.writes)
+	}
+
+	clock.t = clock.t.Add(time.Second)
+	logger.Info("attempt three")
+	logger.FlushIf(errors.New("test error"))
+	if w.writes != 2 {
+		t.Errorf("Expected 2 writes after the cooldown, got %d", w.writes)
+	}
+}
+
+func TestWithFlushCooldown_Pooled(t *testing.T) {
+	cooldown := WithFlushCooldown(time.Minute)
+	for range 3 {
+		w := &countingWriter{}
+		logger := FromContext(WithLogger(context.Background(), WithWriter(w), cooldown))
+		logger.Info("info message")
+		logger.FlushIf(errors.New("test error"))
+		if w.writes != 1 {
+			t.Errorf("Expected pooled loggers to bypass the cooldown, got %d writes", w.writes)
+		}
+	}
+}
+
+func TestCooldownPruning(t *testing.T) {
+	c := &cooldown{window: time.Second, last: make(map[string]time.Time)}
+	start := time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		c.allow(fmt.Sprint(i), start.Add(time.Duration(i)*time.Millisecond))
+	}
+	if !c.allow("late", start.Add(2*time.Second)) {
+		t.Fatal("Expected a new ID to be allowed")
+	}
+	if len(c.last) != 1 || len(c.order) != 1 {
+		t.Errorf("Expected expired windows to be pruned, got %d IDs and %d windows", len(c.last), len(c.order))
+	}
+}
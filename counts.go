@@ -0,0 +1,55 @@
+package failtrace
+
+import "sync/atomic"
+
+// levelCounts counts the entries appended per level, process-wide.
+var levelCounts [256]atomic.Uint64
+
+// countedLevels are the levels a logger counts on its own, to add them to
+// levelCounts in one go instead of contending on the shared counters for
+// every entry.
+var countedLevels = [...]Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// count counts an entry appended at level.
+func (l *requestLogger) count(level Level) {
+	switch {
+	case l.noop:
+		levelCounts[level].Add(1)
+	case level == DebugLevel:
+		l.counts[0]++
+	case level == InfoLevel:
+		l.counts[1]++
+	case level == WarnLevel:
+		l.counts[2]++
+	case level == ErrorLevel:
+		l.counts[3]++
+	default:
+		levelCounts[level].Add(1)
+	}
+}
+
+// foldCounts adds the entries counted by l to levelCounts.
+func (l *requestLogger) foldCounts() {
+	for i, n := range l.counts {
+		if n > 0 {
+			levelCounts[countedLevels[i]].Add(uint64(n))
+		}
+	}
+	l.counts = [len(countedLevels)]uint32{}
+}
+
+// LevelCounts returns a snapshot of how many entries have been logged at each
+// level by all loggers of the process, including the noop logger returned by
+// FromContext for contexts without a logger. Levels without entries are
+// omitted. Loggers add their entries at the standard levels once they are
+// flushed, discarded or reset, so requests still in flight are not counted
+// yet.
+func LevelCounts() map[Level]uint64 {
+	counts := make(map[Level]uint64)
+	for i := range levelCounts {
+		if n := levelCounts[i].Load(); n > 0 {
+			counts[Level(i)] = n
+		}
+	}
+	return counts
+}
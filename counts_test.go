@@ -0,0 +1,62 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestLevelCounts(t *testing.T) {
+	before := LevelCounts()
+
+	var wg sync.WaitGroup
+	const numGoroutines = 50
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := FromContext(WithLogger(context.Background()))
+			logger.Debug("debug message")
+			logger.Debugf("debug message %d", 2)
+			logger.Warn("warn message")
+			logger.FlushIf(nil)
+
+			FromContext(context.Background()).Error("noop error")
+		}()
+	}
+	wg.Wait()
+
+	after := LevelCounts()
+	expected := map[Level]uint64{
+		DebugLevel: 2 * numGoroutines,
+		WarnLevel:  numGoroutines,
+		ErrorLevel: numGoroutines,
+	}
+	for level, n := range expected {
+		if got := after[level] - before[level]; got != n {
+			t.Errorf("Level %c: expected %d new entries, got %d", level, n, got)
+		}
+	}
+}
+
+func TestLevelCounts_Folded(t *testing.T) {
+	before := LevelCounts()
+	logger := New(io.Discard)
+	logger.Info("info message")
+	logger.Info("info message")
+	if got := LevelCounts()[InfoLevel] - before[InfoLevel]; got != 0 {
+		t.Errorf("Expected entries of a request in flight not to be counted yet, got %d", got)
+	}
+
+	logger.Flush()
+	if got := LevelCounts()[InfoLevel] - before[InfoLevel]; got != 2 {
+		t.Errorf("Expected 2 entries counted once flushed, got %d", got)
+	}
+	logger.Flush()
+	if got := LevelCounts()[InfoLevel] - before[InfoLevel]; got != 2 {
+		t.Errorf("Expected the entries to be counted once, got %d", got)
+	}
+}
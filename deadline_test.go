@@ -0,0 +1,45 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadlineAnnotation(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	ctx, cancel := context.WithDeadline(context.Background(), clock.t.Add(50*time.Millisecond))
+	defer cancel()
+
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(ctx, WithWriter(&buf), WithClock(clock)))
+	id := logger.ID()
+
+	logger.Debug("debug message")
+	clock.t = clock.t.Add(38 * time.Millisecond)
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "] deadline_remaining=12ms\n" +
+		"[" + id + "] D: debug message\n" +
+		"[" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestDeadlineAnnotation_NoDeadline(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	if strings.Contains(buf.String(), "deadline_remaining") {
+		t.Errorf("Expected no annotation without a deadline, got %q", buf.String())
+	}
+}
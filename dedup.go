@@ -0,0 +1,46 @@
+package failtrace
+
+import "fmt"
+
+// WithGlobalDedup collapses, at flush, all buffered entries sharing the same
+// level and message into their first occurrence, annotated with "(x N total)".
+// Entries keep the order of their first appearance. Useful when the same
+// warning is scattered across a trace, e.g. by a retry loop.
+func WithGlobalDedup() Option {
+	return func(l *requestLogger) {
+		l.globalDedup = true
+	}
+}
+
+// dedupKey identifies entries collapsed by WithGlobalDedup.
+type dedupKey struct {
+	level   Level
+	message string
+}
+
+// dedupAll returns entries with duplicates collapsed into their first
+// occurrence. The input slice is not modified.
+func dedupAll(entries []logEntry) []logEntry {
+	counts := make(map[dedupKey]int, len(entries))
+	for _, e := range entries {
+		counts[dedupKey{e.level, e.message}]++
+	}
+	if len(counts) == len(entries) {
+		return entries
+	}
+
+	out := make([]logEntry, 0, len(counts))
+	for _, e := range entries {
+		k := dedupKey{e.level, e.message}
+		n, ok := counts[k]
+		if !ok {
+			continue
+		}
+		delete(counts, k)
+		if n > 1 {
+			e.message = fmt.Sprintf("%s (x %d total)", k.message, n)
+		}
+		out = append(out, e)
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWithGlobalDedup(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithGlobalDedup()(logger)
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("upstream slow")
+		logger.Infof("attempt %d", i)
+	}
+	logger.Debug("upstream slow")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] W: upstream slow (x 3 total)\n" +
+		"[test-123] I: attempt 0\n" +
+		"[test-123] I: attempt 1\n" +
+		"[test-123] I: attempt 2\n" +
+		"[test-123] D: upstream slow\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
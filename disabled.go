@@ -0,0 +1,18 @@
+//go:build failtrace_disabled
+
+package failtrace
+
+// disabled compiles logging away when building with the failtrace_disabled
+// tag, for latency-critical release builds:
+//
+//	go build -tags failtrace_disabled ./...
+//
+// Every logging call, including DebugStatic, the key/value variants and the
+// package-level functions, appends nothing; Debug, Info, Warn, Error and
+// their formatted variants return before formatting their arguments. FlushIf
+// writes nothing, not even the error line, but still returns the logger to
+// the pool, so call sites need no change and loggers stay cheap to create.
+// The tradeoff is that such builds keep no trace at all: a failing request in
+// production leaves nothing to debug with. Other flush methods, such as Flush
+// or FlushIfAt, still work, but only see an empty buffer.
+const disabled = true
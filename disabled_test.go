@@ -0,0 +1,66 @@
+//go:build failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"unique"
+)
+
+// Run with: go test -tags failtrace_disabled -run Disabled .
+func TestDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+	err := errors.New("test error")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Debug("debug message")
+		logger.Infof("request %d", 42)
+		logger.Warn("warn message")
+		logger.Errorf("failed: %v", err)
+		logger.FlushIf(err)
+	})
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestDisabled_EveryEntryPoint(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.DebugStatic(unique.Make("static message"))
+	logger.With("k", "v").Infow("info message", "a", 1)
+	logger.FlushIfAt(WarnLevel, nil)
+
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	Info("global message")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
+
+func TestDisabled_Pooled(t *testing.T) {
+	ctx := context.Background()
+	err := errors.New("test error")
+	allocs := testing.AllocsPerRun(100, func() {
+		log := FromContext(WithLogger(ctx))
+		log.Debug("debug message")
+		log.FlushIf(err)
+	})
+
+	// Only the context carrying the logger is allocated; the logger itself
+	// comes from the pool.
+	if allocs > 1 {
+		t.Errorf("Expected at most 1 allocation per request, got %v", allocs)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+// disabled compiles logging away; see disabled.go.
+const disabled = false
@@ -0,0 +1,83 @@
+package failtrace
+
+import (
+	"bytes"
+	"io"
+)
+
+// Encoder serializes flushed entries. Encode writes a single entry and
+// EncodeError the line of the flush error, so custom formats can be plugged in
+// with WithEncoder without further options.
+type Encoder interface {
+	Encode(w io.Writer, id string, e Entry) error
+	EncodeError(w io.Writer, id string, err error) error
+}
+
+// WithEncoder renders flushed entries with enc instead of the built-in text
+// or JSON rendering. The flush error is passed to EncodeError after the
+// buffered entries; header, correlation, deadline and timing lines are not
+// written. Without it, flushes are rendered as by TextEncoder, or JSONEncoder
+// with WithJSON.
+func WithEncoder(enc Encoder) Option {
+	return func(l *requestLogger) {
+		l.encoder = enc
+	}
+}
+
+// TextEncoder renders "[id][name] L: message" lines, as DefaultFormatter does.
+type TextEncoder struct{}
+
+// Encode implements Encoder.
+func (TextEncoder) Encode(w io.Writer, id string, e Entry) error {
+	_, err := w.Write(DefaultFormatter(id, e))
+	return err
+}
+
+// EncodeError implements Encoder, rendering err as an error line.
+func (enc TextEncoder) EncodeError(w io.Writer, id string, err error) error {
+	return enc.Encode(w, id, Entry{Level: ErrorLevel, Message: err.Error()})
+}
+
+// JSONEncoder renders one JSON object per line, as WithJSON does.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(w io.Writer, id string, e Entry) error {
+	var b bytes.Buffer
+	(&requestLogger{}).writeJSON(&b, id, e)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// EncodeError implements Encoder, rendering err as an error object.
+func (enc JSONEncoder) EncodeError(w io.Writer, id string, err error) error {
+	return enc.Encode(w, id, Entry{Level: ErrorLevel, Message: err.Error()})
+}
+
+// encode renders the lead and buffered entries, the flush error err and the
+// remaining trailing entries, such as stack frames, with the encoder. It
+// returns the first encoding error.
+func (l *requestLogger) encode(b *bytes.Buffer, err error, entries []logEntry, lead []Entry, trail ...Entry) error {
+	id := l.ID()
+	for _, e := range l.snapshot(entries, lead) {
+		if encErr := l.encoder.Encode(b, id, e); encErr != nil {
+			return encErr
+		}
+	}
+	if err != nil && !l.noErrorLine {
+		// The error lines built by the flush are replaced by the encoded error.
+		trail = trail[len(l.errorLines(err)):]
+		if l.rootCause {
+			err = rootCause(err)
+		}
+		if encErr := l.encoder.EncodeError(b, id, err); encErr != nil {
+			return encErr
+		}
+	}
+	for _, e := range trail {
+		if encErr := l.encoder.Encode(b, id, e); encErr != nil {
+			return encErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// countingEncoder renders "level|message" lines and counts its calls.
+type countingEncoder struct {
+	entries, errors int
+}
+
+func (c *countingEncoder) Encode(w io.Writer, id string, e Entry) error {
+	c.entries++
+	_, err := fmt.Fprintf(w, "%c|%s\n", e.Level, e.Message)
+	return err
+}
+
+func (c *countingEncoder) EncodeError(w io.Writer, id string, err error) error {
+	c.errors++
+	_, werr := fmt.Fprintf(w, "error|%s\n", err)
+	return werr
+}
+
+func TestWithEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := &countingEncoder{}
+	logger := New(&buf, WithEncoder(enc))
+	logger.Debug("one")
+	logger.Info("two")
+	logger.FlushIf(errors.New("boom"))
+
+	expected := "D|one\nI|two\nerror|boom\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if enc.entries != 2 || enc.errors != 1 {
+		t.Errorf("Expected 2 entries and 1 error encoded, got %d and %d", enc.entries, enc.errors)
+	}
+}
+
+func TestWithEncoderError(t *testing.T) {
+	encErr := errors.New("encode failed")
+	logger := New(io.Discard, WithEncoder(failingEncoder{encErr}))
+	logger.Info("one")
+	if _, err := logger.FlushIfN(errors.New("boom")); err != encErr {
+		t.Errorf("Expected %v, got %v", encErr, err)
+	}
+}
+
+type failingEncoder struct{ err error }
+
+func (f failingEncoder) Encode(io.Writer, string, Entry) error      { return f.err }
+func (f failingEncoder) EncodeError(io.Writer, string, error) error { return f.err }
+
+func TestBuiltinEncoders(t *testing.T) {
+	tests := []struct {
+		enc      Encoder
+		expected string
+	}{
+		{TextEncoder{}, "[test-123] I: hello\n[test-123] E: boom\n"},
+		{JSONEncoder{}, `{"id":"test-123","level":"I","message":"hello"}` + "\n" +
+			`{"id":"test-123","level":"E","message":"boom"}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf, encoder: tt.enc}
+		logger.Info("hello")
+		logger.FlushIf(errors.New("boom"))
+		if buf.String() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+		}
+	}
+}
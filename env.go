@@ -0,0 +1,27 @@
+package failtrace
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// defaultBufCap is the initial capacity of a logger's buffer.
+const defaultBufCap = 32
+
+// bufCap is the initial buffer capacity of new loggers, configurable through
+// the FAILTRACE_BUFCAP environment variable.
+var bufCap atomic.Int64
+
+func init() {
+	loadEnv()
+}
+
+// loadEnv reads the package configuration from the environment. Invalid values
+// are ignored and the defaults retained.
+func loadEnv() {
+	bufCap.Store(defaultBufCap)
+	if n, err := strconv.Atoi(os.Getenv("FAILTRACE_BUFCAP")); err == nil && n > 0 {
+		bufCap.Store(int64(n))
+	}
+}
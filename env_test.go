@@ -0,0 +1,36 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"io"
+	"testing"
+)
+
+func TestLoadEnv_BufCap(t *testing.T) {
+	t.Cleanup(loadEnv)
+	defer func(est uint64) { sizeEstimate.Store(est) }(sizeEstimate.Load())
+	sizeEstimate.Store(0)
+
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"128", 128},
+		{"not-a-number", defaultBufCap},
+		{"-4", defaultBufCap},
+		{"", defaultBufCap},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("FAILTRACE_BUFCAP", tt.value)
+		loadEnv()
+
+		if got := cap(pool.New().(*requestLogger).buf); got != tt.expected {
+			t.Errorf("FAILTRACE_BUFCAP=%q: expected pooled capacity %d, got %d", tt.value, tt.expected, got)
+		}
+		if got := cap(New(io.Discard).buf); got != tt.expected {
+			t.Errorf("FAILTRACE_BUFCAP=%q: expected standalone capacity %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+}
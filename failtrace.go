@@ -33,17 +33,24 @@
 package failtrace
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"os"
+	"slices"
+	"strings"
 	"sync"
-
-	"github.com/google/uuid"
+	"time"
+	"unicode/utf8"
 )
 
 type ctxKey struct{}
 
+// Level is the severity of an entry, rendered as its character in the text
+// format. Applications may add their own levels with RegisterLevel.
 type Level byte
 
 const (
@@ -51,31 +58,280 @@ const (
 	InfoLevel  Level = 'I'
 	WarnLevel  Level = 'W'
 	ErrorLevel Level = 'E'
+	FatalLevel Level = 'F'
 )
 
+// Entry is a single buffered log entry, as handed to custom formatters, hooks
+// and sinks. It is a value: changing it does not affect the buffer.
+type Entry struct {
+	Level   Level
+	Message string
+	Name    string
+	Time    time.Time
+	// Seq is the 1-based position of the entry in its request, set by
+	// WithSequence. It is 0 for lines synthesized at flush time.
+	Seq uint32
+}
+
 type logEntry struct {
-	level   Level
+	level Level
+	// name is the 1-based index of the entry's component name in the names
+	// table of its logger, see Named. Like tag and meta, it fits in the
+	// padding after level, so it costs no space. Names past the 255th of a
+	// request are kept in the entry's meta record instead.
+	name uint8
+	// tag is the 1-based ID of the tag of the entry, see Tagged.
+	tag uint16
+	// meta is the 1-based index of the entry's time and sequence number in
+	// the side slice of its logger, only filled when WithTimestamps or
+	// WithSequence ask for them. The index travels with the entry, so it
+	// stays valid when the buffer is resliced or filtered.
+	meta    uint32
 	message string
 }
 
+// entryMeta holds the rarely needed details of an entry, kept out of line to
+// keep the buffer compact for the common case.
+type entryMeta struct {
+	time time.Time
+	seq  uint32
+	// name is the 1-based index of the entry's name when it does not fit
+	// in the entry.
+	name uint32
+}
+
+// at returns the time e was logged, or the zero time if it was not stamped.
+func (l *requestLogger) at(e logEntry) time.Time {
+	if e.meta == 0 {
+		return time.Time{}
+	}
+	return l.meta[e.meta-1].time
+}
+
+// entry returns the public view of e.
+func (l *requestLogger) entry(e logEntry) Entry {
+	pub := Entry{Level: e.level, Message: e.message, Name: l.nameOf(e)}
+	if e.meta != 0 {
+		m := l.meta[e.meta-1]
+		pub.Time, pub.Seq = m.time, m.seq
+	}
+	return pub
+}
+
+// nameOf returns the component name of e.
+func (l *requestLogger) nameOf(e logEntry) string {
+	i := uint32(e.name)
+	if i == 0 && e.meta != 0 {
+		i = l.meta[e.meta-1].name
+	}
+	if i == 0 {
+		return ""
+	}
+	return l.names[i-1]
+}
+
+// setName records name as the component name of e, in e itself or, past the
+// 255th name of the request, in its meta record m.
+func (l *requestLogger) setName(e *logEntry, m *entryMeta, name string) {
+	i := slices.Index(l.names, name)
+	if i < 0 {
+		l.names = append(l.names, name)
+		i = len(l.names) - 1
+	}
+	if i < math.MaxUint8 {
+		e.name = uint8(i + 1)
+	} else {
+		m.name = uint32(i + 1)
+	}
+}
+
+// addMeta stores m in the side slice and returns its 1-based index.
+func (l *requestLogger) addMeta(m entryMeta) uint32 {
+	l.meta = append(l.meta, m)
+	return uint32(len(l.meta))
+}
+
 type requestLogger struct {
-	id  string
-	buf []logEntry
-	w   io.Writer
+	id     string
+	start  time.Time
+	buf    []logEntry
+	w      io.Writer
+	name   string
+	worker string
+	host   string
+	prefix string
+	color  colorMode
+	eol    string
+	format func(id string, e Entry) []byte
+	hooks  []func(info FlushInfo)
+	limit  *rateLimiter
+	sep    string
+
+	// fields are rendered as " key=value" after the message of every line.
+	// The first baseFields of them were set by options, which Reset keeps.
+	fields     []field
+	baseFields int
+	ctxKeys    []any
+	// with holds the fields of a child scope created by With, rendered after
+	// the message of the entries logged through it.
+	with []field
+	// tag is the ID of the tag of a child scope created by Tagged.
+	tag uint16
+
+	attachments []attachment
+
+	// stamp records the time of every entry.
+	stamp      bool
+	timings    bool
+	timeLayout string
+	meta       []entryMeta
+	// names holds the component names of the buffered entries.
+	names []string
+
+	tail            int
+	indentMultiline bool
+	maxMsg          int
+	growthWarn      int
+	maxBytes        int
+	bufBytes        int
+	droppedBytes    int
+	paused          bool
+	deterministic   bool
+	sequence        bool
+	seq             uint32
+	counts          [len(countedLevels)]uint32
+	grown           bool
+	minLevel        Level
+	sampleRate      float64
+	adaptive        bool
+	passthrough     bool
+	globalDedup     bool
+	noErrorLine     bool
+	inherit         bool
+	correlation     string
+	skipDebugOnly   bool
+	eagerError      bool
+	eagerFlushed    bool
+	rootCause       bool
+	leakCheck       bool
+	stackDepth      int
+	stack           []Entry
+	palette         map[Level]string
+
+	json      bool
+	header    bool
+	jsonArray bool
+
+	scheme IDScheme
+
+	shouldFlush func(err error) bool
+	encoder     Encoder
+	async       *WorkerPool
+	cooldown    *cooldown
+	slog        *slog.Logger
+	ch          chan<- Entry
+	outputs     []output
+	streams     []levelStream
+	clock       Clock
+
+	// deadline is the deadline of the context the logger was installed in.
+	deadline time.Time
+
+	// detached loggers are never returned to the pool.
+	detached bool
+	// flushed is set while a pooled logger sits in the pool.
+	flushed bool
+	// noop is set on the logger of contexts without one, which is never
+	// flushed, so its entries are counted at once.
+	noop bool
+
+	// root is the logger owning the buffer for child scopes created by Named.
+	root *requestLogger
+	// inherited is set on the scopes of a logger reused by WithInherit, whose
+	// flush methods are no-ops: only the layer that installed the logger
+	// flushes it and returns it to the pool.
+	inherited bool
+	// held keeps a flushed logger out of the pool until release, so the
+	// layer that installed it can still ask whether it was flushed.
+	held bool
 }
 
+// now returns the current time for loggers without WithClock; replaced in
+// tests.
+var now = time.Now
+
 var pool = sync.Pool{
 	New: func() any {
 		return &requestLogger{
-			buf: make([]logEntry, 0, 32),
+			buf: make([]logEntry, 0, presizedCap()),
 			w:   os.Stderr,
 		}
 	},
 }
 
-// WithLogger returns a new context with logger.
-func WithLogger(ctx context.Context) context.Context {
-	return context.WithValue(ctx, ctxKey{}, pool.Get().(*requestLogger).reset())
+// WithLogger returns a new context with logger, configured by the default
+// options stored in ctx by WithDefaults, then by the given options. If ctx has
+// a deadline, flushes start with a "[id] deadline_remaining=12ms" line telling
+// how much time was left.
+func WithLogger(ctx context.Context, opts ...Option) context.Context {
+	l := pool.Get().(*requestLogger).reset()
+	defaults, _ := ctx.Value(defaultsKey{}).([]Option)
+	for _, opt := range defaults {
+		opt(l)
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.baseFields = len(l.fields)
+	if parent, ok := ctx.Value(ctxKey{}).(*requestLogger); ok && l.inherit {
+		child := &requestLogger{w: parent.w, name: parent.name, with: parent.with, tag: parent.tag, root: parent.owner()}
+		if l.name != "" {
+			child = parent.Named(l.name)
+		}
+		child.inherited = true
+		l.clearLeakCheck()
+		l.reset().flushed = true
+		pool.Put(l)
+		return context.WithValue(ctx, ctxKey{}, child)
+	}
+	l.seed(ctx)
+	if !l.deterministic {
+		l.deadline, _ = ctx.Deadline()
+	}
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+type defaultsKey struct{}
+
+// WithDefaults returns a new context carrying opts as default options for
+// every logger installed below it by WithLogger. Options passed to WithLogger
+// are applied after the defaults and so override them. Defaults of nested
+// WithDefaults calls accumulate.
+//
+// Usage example:
+//
+//	ctx = failtrace.WithDefaults(ctx, failtrace.WithWriter(out), failtrace.WithMinLevel(failtrace.InfoLevel))
+func WithDefaults(ctx context.Context, opts ...Option) context.Context {
+	defaults, _ := ctx.Value(defaultsKey{}).([]Option)
+	return context.WithValue(ctx, defaultsKey{}, append(defaults[:len(defaults):len(defaults)], opts...))
+}
+
+// New returns a standalone logger writing to w that is never returned to the
+// pool. Flushing a standalone logger clears its buffer but keeps its ID and
+// options, so it can be reused across many flush cycles, e.g. by long-lived
+// background workers.
+func New(w io.Writer, opts ...Option) *requestLogger {
+	l := &requestLogger{
+		buf:      make([]logEntry, 0, bufCap.Load()),
+		w:        w,
+		start:    now(),
+		detached: true,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.baseFields = len(l.fields)
+	return l
 }
 
 // FromContext retrieves the logger from the context.
@@ -84,10 +340,27 @@ func FromContext(ctx context.Context) *requestLogger {
 		return rl
 	}
 	return &requestLogger{
-		id:  "noop",
-		buf: make([]logEntry, 0),
-		w:   io.Discard,
+		id:   "noop",
+		buf:  make([]logEntry, 0),
+		w:    io.Discard,
+		noop: true,
+	}
+}
+
+// IDFromContext returns the request ID of the logger stored in the context.
+// The boolean is false if the context carries no logger.
+func IDFromContext(ctx context.Context) (string, bool) {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		return rl.ID(), true
 	}
+	return "", false
+}
+
+// HasLogger reports whether a logger has been installed in the context by
+// WithLogger, as opposed to the noop logger FromContext falls back to.
+func HasLogger(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxKey{}).(*requestLogger)
+	return ok
 }
 
 // Debug logs an debug-level message. takes string as input.
@@ -97,7 +370,10 @@ func FromContext(ctx context.Context) *requestLogger {
 //	logger := &requestLogger{}
 //	logger.Debug("failed to process request")
 func (l *requestLogger) Debug(msg string) {
-	l.buf = append(l.buf, logEntry{DebugLevel, msg})
+	if disabled {
+		return
+	}
+	l.log(DebugLevel, msg)
 }
 
 // Debugf logs an debug-level message.
@@ -108,7 +384,10 @@ func (l *requestLogger) Debug(msg string) {
 //	logger := &requestLogger{}
 //	logger.Debugf("failed to process request: %v", err)
 func (l *requestLogger) Debugf(format string, args ...any) {
-	l.buf = append(l.buf, logEntry{DebugLevel, fmt.Sprintf(format, args...)})
+	if disabled {
+		return
+	}
+	l.log(DebugLevel, fmt.Sprintf(format, args...))
 }
 
 // Info logs an info-level message. takes string as input.
@@ -118,7 +397,10 @@ func (l *requestLogger) Debugf(format string, args ...any) {
 //	logger := &requestLogger{}
 //	logger.Info("failed to process request")
 func (l *requestLogger) Info(msg string) {
-	l.buf = append(l.buf, logEntry{InfoLevel, msg})
+	if disabled {
+		return
+	}
+	l.log(InfoLevel, msg)
 }
 
 // Infof logs an info-level message.
@@ -129,7 +411,10 @@ func (l *requestLogger) Info(msg string) {
 //	logger := &requestLogger{}
 //	logger.Infof("failed to process request: %v", err)
 func (l *requestLogger) Infof(format string, args ...any) {
-	l.buf = append(l.buf, logEntry{InfoLevel, fmt.Sprintf(format, args...)})
+	if disabled {
+		return
+	}
+	l.log(InfoLevel, fmt.Sprintf(format, args...))
 }
 
 // Warn logs an warn-level message. takes string as input.
@@ -139,7 +424,10 @@ func (l *requestLogger) Infof(format string, args ...any) {
 //	logger := &requestLogger{}
 //	logger.Warn("failed to process request")
 func (l *requestLogger) Warn(msg string) {
-	l.buf = append(l.buf, logEntry{WarnLevel, msg})
+	if disabled {
+		return
+	}
+	l.log(WarnLevel, msg)
 }
 
 // Warnf logs an warn-level message.
@@ -150,7 +438,10 @@ func (l *requestLogger) Warn(msg string) {
 //	logger := &requestLogger{}
 //	logger.Warnf("failed to process request: %v", err)
 func (l *requestLogger) Warnf(format string, args ...any) {
-	l.buf = append(l.buf, logEntry{WarnLevel, fmt.Sprintf(format, args...)})
+	if disabled {
+		return
+	}
+	l.log(WarnLevel, fmt.Sprintf(format, args...))
 }
 
 // Errorf logs an error-level message.
@@ -161,7 +452,10 @@ func (l *requestLogger) Warnf(format string, args ...any) {
 //	logger := &requestLogger{}
 //	logger.Errorf("failed to process request: %v", err)
 func (l *requestLogger) Errorf(format string, args ...any) {
-	l.buf = append(l.buf, logEntry{ErrorLevel, fmt.Sprintf(format, args...)})
+	if disabled {
+		return
+	}
+	l.log(ErrorLevel, fmt.Sprintf(format, args...))
 }
 
 // Error logs an error-level message. takes string as input.
@@ -171,47 +465,856 @@ func (l *requestLogger) Errorf(format string, args ...any) {
 //	logger := &requestLogger{}
 //	logger.Error("failed to process request")
 func (l *requestLogger) Error(msg string) {
-	l.buf = append(l.buf, logEntry{ErrorLevel, msg}) // Should be ErrorLevel, not WarnLevel
+	if disabled {
+		return
+	}
+	l.log(ErrorLevel, msg)
+}
+
+// Named returns a child scope of the logger whose entries are tagged with name.
+// Entries logged through the child end up in the same buffer as the parent, and
+// nested names are dot-joined, e.g. "auth.token".
+//
+// Usage example:
+//
+//	log := failtrace.FromContext(ctx).Named("auth")
+//	log.Named("token").Debug("validating token") // [id][auth.token] D: validating token
+func (l *requestLogger) Named(name string) *requestLogger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &requestLogger{
+		w:         l.w,
+		name:      name,
+		with:      l.with,
+		tag:       l.tag,
+		root:      l.owner(),
+		inherited: l.inherited,
+	}
+}
+
+// ID returns the request ID of the logger. The ID is generated on first use,
+// so requests that are discarded without being written never pay for it.
+func (l *requestLogger) ID() string {
+	o := l.owner()
+	if o.id == "" {
+		o.id = o.scheme.newID()
+	}
+	return o.id
+}
+
+// Writer returns the destination the logger flushes to, io.Discard for the
+// noop logger of a context without one, so integrations can frame the trace
+// on the same destination. Writing to it directly bypasses the buffer and may
+// interleave with flush output.
+func (l *requestLogger) Writer() io.Writer {
+	return l.owner().w
+}
+
+// owner returns the logger holding the buffer.
+func (l *requestLogger) owner() *requestLogger {
+	if l.root != nil {
+		return l.root
+	}
+	return l
+}
+
+// log appends an entry to the owning buffer.
+func (l *requestLogger) log(level Level, msg string) {
+	if disabled || l.owner().paused {
+		return
+	}
+	if len(l.with) > 0 {
+		msg = appendFields(msg, l.with)
+	}
+	if max := l.owner().maxMsg; max > 0 && len(msg) > max {
+		msg = truncate(msg, max)
+	}
+	l.append(logEntry{level: level, message: msg})
+}
+
+const truncatedSuffix = " …(truncated)"
+
+// truncate cuts msg to at most max bytes, without splitting a UTF-8 sequence,
+// and marks it as truncated.
+func truncate(msg string, max int) string {
+	for max > 0 && !utf8.RuneStart(msg[max]) {
+		max--
+	}
+	return msg[:max] + truncatedSuffix
+}
+
+// append adds e to the owning buffer, tagged with the logger's name.
+func (l *requestLogger) append(e logEntry) {
+	o := l.owner()
+	if disabled || o.paused {
+		return
+	}
+	o.count(e.level)
+	if o.minLevel != 0 && e.level.rank() < o.minLevel.rank() {
+		return
+	}
+	e.tag = l.tag
+	var m entryMeta
+	if o.stamp {
+		m.time = o.now()
+	}
+	if o.sequence {
+		o.seq++
+		m.seq = o.seq
+	}
+	if o.passthrough || o.eagerFlushed {
+		o.writeNow(Entry{Level: e.level, Message: e.message, Name: l.name, Time: m.time, Seq: m.seq})
+		return
+	}
+	if l.name != "" {
+		o.setName(&e, &m, l.name)
+	}
+	if o.stamp || o.sequence || m.name != 0 {
+		e.meta = o.addMeta(m)
+	}
+	o.push(e)
+	if o.eagerError && e.level.rank() >= ErrorLevel.rank() {
+		o.write(nil, o.buf, nil)
+		o.buf = o.buf[:0]
+		o.meta = o.meta[:0]
+		o.bufBytes = 0
+		o.eagerFlushed = true
+		return
+	}
+
+	if o.growthWarn > 0 && !o.grown && len(o.buf) > o.growthWarn {
+		o.grown = true
+		warn := logEntry{level: WarnLevel, message: fmt.Sprintf("log buffer exceeded %d entries", o.growthWarn)}
+		if o.stamp {
+			warn.meta = o.addMeta(entryMeta{time: m.time})
+		}
+		o.push(warn)
+	}
+}
+
+// Range calls fn for each buffered entry in order, until fn returns false.
+// It reads the buffer in place without copying it, so custom sinks can
+// inspect a trace without allocating.
+func (l *requestLogger) Range(fn func(Entry) bool) {
+	o := l.owner()
+	for _, e := range o.buf {
+		if !fn(o.entry(e)) {
+			return
+		}
+	}
 }
 
 // FlushIf writes buffered log entries and the given error to the writer if err is not nil,
 // then returns the logger to the pool.
 func (l *requestLogger) FlushIf(err error) {
+	if l.inherited {
+		return
+	}
+	if disabled {
+		// Nothing was buffered: only return the logger to the pool.
+		err = nil
+	}
+	l.owner().flushQueued(ErrorLevel, err)
+}
+
+// FlushIfAt behaves like FlushIf, rendering the error line at the given level,
+// e.g. WarnLevel for soft errors.
+func (l *requestLogger) FlushIfAt(level Level, err error) {
+	if l.inherited {
+		return
+	}
+	l.owner().flushQueued(level, err)
+}
+
+// FlushIfAndEntries behaves like FlushIf and returns a copy of the entries
+// that were written, including the trailing error entry. It returns nil if
+// nothing was written.
+func (l *requestLogger) FlushIfAndEntries(err error) []Entry {
+	if l.inherited {
+		return nil
+	}
+	var written []Entry
+	l.owner().flushIf(ErrorLevel, err, &written)
+	return written
+}
+
+// FlushIfN behaves like FlushIf and returns the number of bytes written and
+// the error of the writer, if any.
+func (l *requestLogger) FlushIfN(err error) (int, error) {
+	if l.inherited {
+		return 0, nil
+	}
+	return l.owner().flushIf(ErrorLevel, err, nil)
+}
+
+// FlushIfReader behaves like FlushIf, but renders the output into memory
+// instead of the writer and returns a reader over it. The logger is returned
+// to the pool once the output has been rendered.
+func (l *requestLogger) FlushIfReader(err error) io.Reader {
+	var b bytes.Buffer
+	if l.inherited {
+		return &b
+	}
+	o := l.owner()
+	w := o.w
+	o.w = &b
+	o.flushIf(ErrorLevel, err, nil)
+	if o.detached {
+		o.w = w
+	}
+	return &b
+}
+
+// WriteTo renders the buffered entries to w, in the format a flush would
+// write, and returns the number of bytes written. Unlike a flush, it keeps the
+// buffer and does not return the logger to the pool, so a trace can be
+// embedded in a larger stream and the request carries on logging.
+func (l *requestLogger) WriteTo(w io.Writer) (int64, error) {
+	o := l.owner()
+	entries := o.buf
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	if o.globalDedup {
+		entries = dedupAll(entries)
+	}
+
+	b := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		b.Reset()
+		bufPool.Put(b)
+	}()
+
+	o.render(b, nil, entries, nil)
+	return writeBuffer(w, b)
+}
+
+// flushIf implements FlushIf, rendering err at level, and returns the number
+// of bytes written and any write error. If written is not nil, it receives a
+// copy of the written entries before the logger is returned to the pool.
+func (l *requestLogger) flushIf(level Level, err error, written *[]Entry) (int, error) {
+	if l.flushed {
+		return 0, nil
+	}
 	defer l.put()
 
+	if err != nil && l.shouldFlush != nil && !l.shouldFlush(err) {
+		err = nil
+	}
+	l.notify(err)
+
+	// In passthrough mode every entry has already been written.
+	if l.passthrough {
+		return 0, nil
+	}
 	if err == nil {
-		return
+		if l.sampled() && !l.coolingDown() {
+			return l.write(nil, l.buf, nil)
+		}
+		return 0, nil
+	}
+	if l.adaptive {
+		errorRatio.observe(true, l.now())
 	}
 
-	for _, entry := range l.buf {
-		if _, wErr := fmt.Fprintf(l.w, "[%s] %c: %s\n", l.id, entry.level, entry.message); wErr != nil {
-			_ = wErr
+	var lead []Entry
+	entries := l.buf
+	if l.skipDebugOnly && debugOnly(entries) {
+		entries = nil
+	}
+	if l.droppedBytes > 0 {
+		lead = append(lead, l.synth(WarnLevel, fmt.Sprintf("... (%d bytes of earlier entries dropped)", l.droppedBytes)))
+	}
+	if l.tail > 0 && len(entries) > l.tail {
+		lead = append(lead, l.synth(InfoLevel, fmt.Sprintf("... (%d earlier entries omitted)", len(entries)-l.tail)))
+		entries = entries[len(entries)-l.tail:]
+	}
+	if l.limit != nil {
+		allowed, suppressed := l.limit.allow(l, err, l.now())
+		if !allowed {
+			return 0, nil
+		}
+		if suppressed > 0 {
+			lead = append([]Entry{l.synth(WarnLevel, fmt.Sprintf("suppressed %d similar errors", suppressed))}, lead...)
 		}
 	}
 
-	if _, wErr := fmt.Fprintf(l.w, "[%s] E: %v\n", l.id, err); wErr != nil {
-		_ = wErr
+	if l.coolingDown() {
+		return 0, nil
 	}
+
+	var trail []Entry
+	if !l.noErrorLine {
+		for _, line := range l.errorLines(err) {
+			trail = append(trail, l.synth(level, line))
+		}
+		trail[len(trail)-1].Message += errFields(err)
+		if l.stackDepth > 0 {
+			trail = append(trail, l.callerStack(level, l.stackDepth)...)
+		}
+		trail = append(trail, l.stack...)
+	}
+	if written != nil {
+		*written = l.snapshot(entries, lead, trail...)
+	}
+	return l.write(err, entries, lead, trail...)
+}
+
+// errorLines returns the messages of the error line of err, one per line when
+// multiline messages are indented.
+func (l *requestLogger) errorLines(err error) []string {
+	if l.rootCause {
+		err = rootCause(err)
+	}
+	msg := err.Error()
+	if l.indentMultiline && strings.Contains(msg, "\n") {
+		return strings.Split(msg, "\n")
+	}
+	return []string{msg}
 }
 
 // Flush writes buffered log entries, then returns the logger to the pool.
 func (l *requestLogger) Flush() {
+	if l.inherited {
+		return
+	}
+	if l.root != nil {
+		l.root.Flush()
+		return
+	}
+	if l.flushed {
+		return
+	}
 	defer l.put()
+	l.notify(nil)
+
+	if !l.coolingDown() {
+		l.write(nil, l.buf, nil)
+	}
+}
+
+// notify calls the OnFlush hooks with the buffered entries and err.
+func (l *requestLogger) notify(err error) {
+	if len(l.hooks) == 0 {
+		return
+	}
+
+	info := FlushInfo{
+		ID:       l.ID(),
+		Entries:  len(l.buf),
+		Err:      err,
+		Duration: l.now().Sub(l.start),
+		Buffered: l.snapshot(l.buf, nil),
+	}
+	for _, hook := range l.hooks {
+		hook(info)
+	}
+}
+
+// synth returns an entry synthesized at flush time, such as the error line.
+func (l *requestLogger) synth(level Level, msg string) Entry {
+	e := Entry{Level: level, Message: msg, Name: l.name}
+	if l.stamp {
+		e.Time = l.now()
+	}
+	return e
+}
+
+// snapshot returns a copy of the lead entries, the given buffered entries and
+// the trailing entries as public entries.
+func (l *requestLogger) snapshot(buffered []logEntry, lead []Entry, trail ...Entry) []Entry {
+	entries := make([]Entry, 0, len(lead)+len(buffered)+len(trail))
+	entries = append(entries, lead...)
+	for _, entry := range buffered {
+		entries = append(entries, l.entry(entry))
+	}
+	return append(entries, trail...)
+}
+
+var bufPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
 
-	for _, entry := range l.buf {
-		if _, wErr := fmt.Fprintf(l.w, "[%s] %c: %s\n", l.id, entry.level, entry.message); wErr != nil {
-			_ = wErr
+// write renders the lead entries, the given buffered entries and the trailing
+// entries such as the line of the flush error err, and hands them to the
+// writer in a single Write call. It returns the number of bytes written and
+// the write error.
+func (l *requestLogger) write(err error, entries []logEntry, lead []Entry, trail ...Entry) (int, error) {
+	if len(entries) == 0 && len(trail) == 0 && !(l.json && l.jsonArray) {
+		return 0, nil
+	}
+	if l.globalDedup {
+		entries = dedupAll(entries)
+	}
+	if l.slog != nil {
+		l.replay(entries, lead, trail)
+		return 0, nil
+	}
+	if l.ch != nil {
+		l.send(entries, lead, trail)
+		return 0, nil
+	}
+	if len(l.outputs) > 0 {
+		return l.writeOutputs(err, entries, lead, trail...)
+	}
+	if len(l.streams) > 0 {
+		return l.writeStreams(err, entries, lead, trail...)
+	}
+	if bw, ok := l.w.(BatchWriter); ok {
+		return 0, bw.WriteBatch(l.ID(), l.snapshot(entries, lead), err)
+	}
+	return l.emit(err, entries, lead, trail...)
+}
+
+// emit renders the entries and writes them to the writer in a single call.
+func (l *requestLogger) emit(err error, entries []logEntry, lead []Entry, trail ...Entry) (int, error) {
+	b := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		b.Reset()
+		bufPool.Put(b)
+	}()
+
+	var encErr error
+	if l.encoder != nil {
+		encErr = l.encode(b, err, entries, lead, trail...)
+		if err != nil {
+			l.writeAttachments(b)
 		}
+	} else {
+		l.render(b, err, entries, lead, trail...)
+	}
+	n, err := writeBuffer(l.w, b)
+	if err == nil {
+		err = syncWriter(l.w)
+	}
+	if err == nil {
+		err = encErr
 	}
+	return int(n), err
+}
+
+// render formats the lead, buffered and trailing entries into b, framed by the
+// optional header, correlation, timings and separator lines, followed by the
+// attachments if err is not nil. The correlation line is left out in JSON
+// mode, and attachments are rendered as JSON records there.
+func (l *requestLogger) render(b *bytes.Buffer, err error, entries []logEntry, lead []Entry, trail ...Entry) {
+	id, theme := l.ID(), l.theme()
+	array := l.json && l.jsonArray
+	if array {
+		b.WriteByte('[')
+	}
+	if l.json && l.header {
+		l.writeHeader(b, id)
+	}
+	if l.correlation != "" && !l.json {
+		fmt.Fprintf(b, l.correlation, id, l.start.Format(time.RFC3339Nano))
+		b.WriteString(l.lineTerminator())
+	}
+	if !l.deadline.IsZero() && !l.json && l.format == nil {
+		fmt.Fprintf(b, "%s[%s%s] deadline_remaining=%s", l.prefix, l.host, id, l.deadline.Sub(l.now()))
+		b.WriteString(l.lineTerminator())
+	}
+	for _, entry := range lead {
+		l.writeEntry(b, id, theme, entry)
+	}
+	for _, entry := range entries {
+		l.writeEntry(b, id, theme, l.entry(entry))
+	}
+	for _, entry := range trail {
+		l.writeEntry(b, id, theme, entry)
+	}
+	if l.timings {
+		if e, ok := l.stepTimings(entries); ok {
+			l.writeEntry(b, id, theme, e)
+		}
+	}
+	if err != nil && l.json {
+		l.writeJSONAttachments(b, id)
+	}
+	if array {
+		b.WriteByte(']')
+		b.WriteString(l.lineTerminator())
+	}
+	b.WriteString(l.sep)
+	if err != nil && !l.json {
+		l.writeAttachments(b)
+	}
+}
+
+// writeNow renders a single entry straight to the writer, bypassing the
+// buffer. It is used in passthrough mode. In JSON array mode the entry is
+// written as an array of its own, so every write stays a complete document.
+func (l *requestLogger) writeNow(e Entry) {
+	b := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		b.Reset()
+		bufPool.Put(b)
+	}()
+
+	array := l.json && l.jsonArray
+	if array {
+		b.WriteByte('[')
+	}
+	l.writeEntry(b, l.ID(), l.theme(), e)
+	if array {
+		b.WriteByte(']')
+		b.WriteString(l.lineTerminator())
+	}
+	writeBuffer(l.stream(e.Level), b)
+}
+
+// writeBuffer drains b into w, letting w read it directly if it implements
+// io.ReaderFrom. Otherwise b is handed to w in a single Write call.
+func writeBuffer(w io.Writer, b *bytes.Buffer) (int64, error) {
+	if rf, ok := w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(b)
+	}
+	return b.WriteTo(w)
+}
+
+// writeEntry renders e into b using the configured formatter, if any.
+func (l *requestLogger) writeEntry(b *bytes.Buffer, id string, theme map[Level]string, e Entry) {
+	switch {
+	case l.encoder != nil:
+		l.encoder.Encode(b, id, e)
+	case l.format != nil:
+		b.Write(l.format(id, e))
+	case l.json:
+		l.writeJSON(b, id, e)
+	default:
+		l.writeLine(b, id, theme, e)
+	}
+}
+
+// DefaultFormatter renders an entry in the built-in "[id][name] L: message"
+// format, terminated by "\n".
+func DefaultFormatter(id string, e Entry) []byte {
+	var b bytes.Buffer
+	(&requestLogger{}).writeLine(&b, id, nil, e)
+	return b.Bytes()
+}
+
+// writeLine renders a single "[id][worker=label][name] L: message" line into b,
+// coloring the level with its escape sequence from theme, if any.
+func (l *requestLogger) writeLine(b *bytes.Buffer, id string, theme map[Level]string, e Entry) {
+	b.WriteString(l.prefix)
+	b.WriteByte('[')
+	b.WriteString(l.host)
+	b.WriteString(id)
+	b.WriteByte(']')
+	if l.worker != "" {
+		b.WriteString("[worker=")
+		b.WriteString(l.worker)
+		b.WriteByte(']')
+	}
+	if e.Name != "" {
+		b.WriteByte('[')
+		b.WriteString(e.Name)
+		b.WriteByte(']')
+	}
+	b.WriteByte(' ')
+	if e.Seq > 0 {
+		writeSeq(b, e.Seq)
+	}
+	if l.timeLayout != "" && !e.Time.IsZero() {
+		b.WriteString(e.Time.Format(l.timeLayout))
+		b.WriteByte(' ')
+	}
+	if color, ok := theme[e.Level]; ok {
+		b.WriteString(color)
+		b.WriteByte(byte(e.Level))
+		b.WriteString(colorReset)
+	} else {
+		b.WriteByte(byte(e.Level))
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	for _, f := range l.fields {
+		b.WriteByte(' ')
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		b.WriteString(f.value)
+	}
+	b.WriteString(l.lineTerminator())
+}
+
+// lineTerminator returns the configured line terminator, defaulting to "\n".
+func (l *requestLogger) lineTerminator() string {
+	if l.eol == "" {
+		return "\n"
+	}
+	return l.eol
+}
+
+// Detach returns a copy of the logger that is not pooled, so it remains safe
+// to use after the original has been flushed and reused by another request.
+// The copy keeps the request ID, writer and options, and clears its own
+// buffer when flushed instead of returning to the pool.
+//
+// Usage example:
+//
+//	trace := log.Detach()
+//	log.FlushIf(nil)
+//	go enrich(trace)
+func (l *requestLogger) Detach() *requestLogger {
+	o := l.owner()
+	o.ID()
+
+	d := *o
+	d.buf = append(make([]logEntry, 0, len(o.buf)), o.buf...)
+	d.meta = append(o.meta[:0:0], o.meta...)
+	d.names = append(o.names[:0:0], o.names...)
+	d.hooks = append(o.hooks[:0:0], o.hooks...)
+	d.fields = append(o.fields[:0:0], o.fields...)
+	d.outputs = append(o.outputs[:0:0], o.outputs...)
+	d.streams = append(o.streams[:0:0], o.streams...)
+	d.attachments = append(o.attachments[:0:0], o.attachments...)
+	d.counts = [len(countedLevels)]uint32{}
+	d.detached = true
+	d.leakCheck = false
+	return &d
+}
+
+// Merge appends the buffered entries of other to the buffer of l, preserving
+// their order, so both are written by a single flush of l. Entries keep the
+// component name they were logged under; entries without one are tagged with
+// the ID of other. other is left untouched and is not returned to the pool.
+//
+// Usage example:
+//
+//	trace := helper(ctx) // returns a detached or standalone logger
+//	log.Merge(trace)
+//	log.FlushIf(err)
+func (l *requestLogger) Merge(other *requestLogger) {
+	o, src := l.owner(), other.owner()
+	if o == src {
+		return
+	}
+	for _, e := range src.buf {
+		name := src.nameOf(e)
+		if name == "" {
+			name = src.ID()
+		}
+		var m entryMeta
+		if e.meta != 0 {
+			m = src.meta[e.meta-1]
+		}
+		m.name, e.name, e.meta = 0, 0, 0
+		o.setName(&e, &m, name)
+		if m != (entryMeta{}) {
+			e.meta = o.addMeta(m)
+		}
+		o.push(e)
+	}
+}
+
+// DrainTo moves the buffered entries to dst like Merge, then clears the buffer
+// and returns the logger to the pool without writing anything, so each entry
+// ends up in dst exactly once.
+//
+// Usage example:
+//
+//	child := failtrace.FromContext(workerCtx)
+//	child.DrainTo(log)
+func (l *requestLogger) DrainTo(dst *requestLogger) {
+	src := l.owner()
+	if l.inherited || src.flushed || src == dst.owner() {
+		return
+	}
+	dst.Merge(src)
+	src.put()
+}
+
+// Peek writes the buffered entries like Flush, but keeps them in the buffer
+// and the logger out of the pool, e.g. to show the trace so far in a debugging
+// tool. A later flush writes every entry again, including those already shown
+// by Peek.
+func (l *requestLogger) Peek() {
+	o := l.owner()
+	if o.flushed {
+		return
+	}
+	o.write(nil, o.buf, nil)
+}
+
+// FlushAndReset writes the buffered log entries and clears the buffer in
+// place, keeping the logger, its ID and options. Unlike Flush it does not
+// return the logger to the pool, so one logger can serve a whole stream of
+// messages.
+func (l *requestLogger) FlushAndReset() {
+	o := l.owner()
+	if l.inherited || o.flushed {
+		return
+	}
+	o.notify(nil)
+	o.write(nil, o.buf, nil)
+	o.clearBuffer()
+}
+
+// Flushed reports whether the logger has been flushed, by Flush, FlushIf,
+// Discard or another flush method, and returned to the pool. Wrappers can use
+// it to avoid redundant flushes. It is false again once the logger is reused
+// from the pool. Standalone loggers created by New stay usable after a flush
+// and always report false.
+func (l *requestLogger) Flushed() bool {
+	return l.owner().flushed
+}
+
+// Reset clears the buffer, attachments and the fields added since the logger
+// was created, e.g. by AddField, and gives it a fresh ID, without writing
+// anything. Its options are kept, including the fields they set, such as
+// WithServiceFields. Reset is intended for standalone loggers created by New;
+// pooled loggers are cleared when they return to the pool and should not be
+// reset by hand.
+func (l *requestLogger) Reset() {
+	if l.inherited {
+		return
+	}
+	o := l.owner()
+	o.clearBuffer()
+	o.fields = o.fields[:o.baseFields]
+	clear(o.attachments)
+	o.attachments = o.attachments[:0]
+	o.id = ""
+	o.start = o.now()
+	o.seq = 0
+}
+
+// clearBuffer empties the buffer of a logger that stays in use, along with
+// the state tied to what was buffered: the byte budget, the growth warning and
+// the switch to immediate writes after an eager error flush.
+func (l *requestLogger) clearBuffer() {
+	l.foldCounts()
+	l.buf = l.buf[:0]
+	l.meta = l.meta[:0]
+	clear(l.names)
+	l.names = l.names[:0]
+	l.bufBytes, l.droppedBytes = 0, 0
+	l.grown, l.eagerFlushed = false, false
+}
+
+// Discard drops the buffered entries without writing them and returns the
+// logger to the pool. It is equivalent to FlushIf(nil), but states the intent.
+func (l *requestLogger) Discard() {
+	if l.inherited {
+		return
+	}
+	l.owner().flushIf(ErrorLevel, nil, nil)
 }
 
 // put resets the logger's buffer and ID, effectively clearing all logs.
+// A fresh ID is generated lazily on the next use. The logger is marked as
+// flushed until it is taken from the pool again, so flushing it twice, e.g.
+// from an explicit FlushIf and a deferred one, does not pool it twice.
+// Detached loggers only clear their buffer. The buffer length is folded into
+// the size estimate used to presize new pooled loggers, and buffers grown far
+// beyond their initial capacity are replaced by a fresh one.
 func (l *requestLogger) put() {
-	pool.Put(l.reset())
+	if l.detached {
+		l.clearBuffer()
+		clear(l.attachments)
+		l.attachments = l.attachments[:0]
+		return
+	}
+	observeSize(len(l.buf))
+	l.clearLeakCheck()
+	held := l.held
+	l.reset().flushed = true
+	if cap(l.buf) > maxPooledGrowth*int(bufCap.Load()) {
+		l.buf = make([]logEntry, 0, presizedCap())
+	}
+	if held {
+		l.held = true
+		return
+	}
+	pool.Put(l)
+}
+
+// release returns a held logger to the pool once it has been flushed.
+func (l *requestLogger) release() {
+	l.held = false
+	pool.Put(l)
 }
 
+// maxPooledGrowth bounds the buffer capacity kept by pooled loggers, as a
+// multiple of the initial capacity. Larger buffers are dropped on put, so a
+// few huge requests do not pin their memory in the pool indefinitely.
+const maxPooledGrowth = 4
+
 func (l *requestLogger) reset() *requestLogger {
+	l.foldCounts()
 	l.buf = l.buf[:0]
-	l.id = uuid.New().String()
+	l.meta = l.meta[:0]
+	clear(l.names)
+	l.names = l.names[:0]
+	l.id = ""
+	l.start = now()
+	l.w = os.Stderr
+	l.name = ""
+	l.worker = ""
+	l.host = ""
+	l.prefix = ""
+	l.sampleRate = 0
+	l.adaptive = false
+	l.passthrough = false
+	l.globalDedup = false
+	l.noErrorLine = false
+	l.inherit = false
+	l.correlation = ""
+	l.skipDebugOnly = false
+	l.eagerError = false
+	l.eagerFlushed = false
+	l.rootCause = false
+	l.stackDepth = 0
+	l.stack = nil
+	l.deadline = time.Time{}
+	l.color = colorOff
+	l.palette = nil
+	l.stamp = false
+	l.timings = false
+	l.timeLayout = ""
+	l.tail = 0
+	l.indentMultiline = false
+	l.maxMsg = 0
+	l.growthWarn = 0
+	l.maxBytes = 0
+	l.bufBytes = 0
+	l.droppedBytes = 0
+	l.paused = false
+	l.deterministic = false
+	l.sequence = false
+	l.seq = 0
+	l.grown = false
+	l.minLevel = 0
+	l.json = false
+	l.header = false
+	l.jsonArray = false
+	l.scheme = UUIDv4
+	l.flushed = false
+	l.held = false
+	l.eol = ""
+	l.format = nil
+	l.hooks = l.hooks[:0]
+	l.limit = nil
+	l.sep = ""
+	l.fields = l.fields[:0]
+	l.baseFields = 0
+	l.with = nil
+	clear(l.attachments)
+	l.attachments = l.attachments[:0]
+	l.ctxKeys = nil
+	l.shouldFlush = nil
+	l.encoder = nil
+	l.async = nil
+	l.cooldown = nil
+	l.slog = nil
+	l.ch = nil
+	l.outputs = l.outputs[:0]
+	clear(l.streams)
+	l.streams = l.streams[:0]
+	l.clock = nil
 	return l
 }
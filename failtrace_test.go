@@ -1,3 +1,5 @@
+//go:build !failtrace_disabled
+
 package failtrace
 
 import (
@@ -6,9 +8,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"reflect"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 func TestRequestLogger_Debug(t *testing.T) {
@@ -185,7 +192,7 @@ func TestWithLogger(t *testing.T) {
 
 	logger := FromContext(newCtx)
 
-	if logger.id == "" {
+	if logger.ID() == "" {
 		t.Error("Expected logger to have ID, got empty string")
 	}
 	if len(logger.buf) != 0 {
@@ -199,7 +206,7 @@ func TestFromContext_WithLogger(t *testing.T) {
 
 	logger := FromContext(ctx)
 
-	if logger.id == "" {
+	if logger.ID() == "" {
 		t.Error("Expected logger to have ID, got empty string")
 	}
 }
@@ -218,6 +225,51 @@ func TestFromContext_NoLogger(t *testing.T) {
 	}
 }
 
+func TestRequestLogger_ID(t *testing.T) {
+	logger := &requestLogger{id: "test-123"}
+
+	if logger.ID() != "test-123" {
+		t.Errorf("Expected 'test-123', got '%s'", logger.ID())
+	}
+	if id := FromContext(context.Background()).ID(); id != "noop" {
+		t.Errorf("Expected 'noop' ID, got '%s'", id)
+	}
+}
+
+func TestIDFromContext_WithLogger(t *testing.T) {
+	ctx := WithLogger(context.Background())
+
+	id, ok := IDFromContext(ctx)
+	if !ok {
+		t.Fatal("Expected ID to be found in context")
+	}
+	if id != FromContext(ctx).ID() {
+		t.Errorf("Expected '%s', got '%s'", FromContext(ctx).ID(), id)
+	}
+}
+
+func TestIDFromContext_NoLogger(t *testing.T) {
+	id, ok := IDFromContext(context.Background())
+	if ok {
+		t.Error("Expected no ID for context without logger")
+	}
+	if id != "" {
+		t.Errorf("Expected empty ID, got '%s'", id)
+	}
+}
+
+func TestHasLogger(t *testing.T) {
+	if HasLogger(context.Background()) {
+		t.Error("Expected no logger in background context")
+	}
+
+	ctx := WithLogger(context.Background())
+	if !HasLogger(ctx) {
+		t.Error("Expected logger to be installed")
+	}
+	FromContext(ctx).FlushIf(nil)
+}
+
 func TestRequestLogger_FlushIf_WithError(t *testing.T) {
 	var buf bytes.Buffer
 	logger := &requestLogger{
@@ -254,6 +306,101 @@ func TestRequestLogger_FlushIf_WithError(t *testing.T) {
 	}
 }
 
+func TestRequestLogger_FlushIfAt(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Debug("debug message")
+	logger.FlushIfAt(WarnLevel, errors.New("soft error"))
+
+	expected := "[test-123] D: debug message\n[test-123] W: soft error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRequestLogger_FlushIfN(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Debug("debug message")
+	n, err := logger.FlushIfN(errors.New("test error"))
+
+	if err != nil {
+		t.Fatalf("Expected no write error, got %v", err)
+	}
+	if n != buf.Len() {
+		t.Errorf("Expected %d bytes, got %d", buf.Len(), n)
+	}
+
+	fw := &failingWriter{failCount: 1}
+	logger = &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   fw,
+	}
+	logger.Debug("debug message")
+	if _, err := logger.FlushIfN(errors.New("test error")); err == nil {
+		t.Error("Expected write error to be returned")
+	}
+}
+
+func TestRequestLogger_FlushIfReader(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+	id := logger.ID()
+
+	logger.Debug("debug message")
+	r := logger.FlushIfReader(errors.New("test error"))
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[" + id + "] D: debug message\n[" + id + "] E: test error\n"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written to the writer, got %q", buf.String())
+	}
+	if !logger.flushed {
+		t.Error("Expected logger to be returned to the pool")
+	}
+}
+
+func TestFlushed(t *testing.T) {
+	for _, flush := range []func(l *requestLogger){
+		func(l *requestLogger) { l.Flush() },
+		func(l *requestLogger) { l.FlushIf(errors.New("test error")) },
+		func(l *requestLogger) { l.Discard() },
+	} {
+		logger := FromContext(WithLogger(context.Background(), WithWriter(io.Discard)))
+		logger.Debug("debug message")
+		if logger.Flushed() {
+			t.Error("Expected a fresh logger not to be flushed")
+		}
+
+		flush(logger)
+		if !logger.Flushed() {
+			t.Error("Expected logger to be flushed")
+		}
+
+		// WithLogger resets loggers taken from the pool.
+		if logger.reset(); logger.Flushed() {
+			t.Error("Expected a reused logger not to be flushed")
+		}
+	}
+}
+
 func TestRequestLogger_FlushIf_NoError(t *testing.T) {
 	var buf bytes.Buffer
 	logger := &requestLogger{
@@ -273,6 +420,87 @@ func TestRequestLogger_FlushIf_NoError(t *testing.T) {
 	}
 }
 
+func TestRequestLogger_FlushIfAndEntries(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Debug("debug message")
+	logger.Named("db").Warn("slow query")
+
+	entries := logger.FlushIfAndEntries(errors.New("test error"))
+
+	expected := []Entry{
+		{Level: DebugLevel, Message: "debug message"},
+		{Level: WarnLevel, Message: "slow query", Name: "db"},
+		{Level: ErrorLevel, Message: "test error"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		if entries[i] != e {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, entries[i])
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(entries) {
+		t.Errorf("Expected %d lines written, got %d", len(entries), len(lines))
+	}
+
+	logger = &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	logger.Debug("debug message")
+	if entries := logger.FlushIfAndEntries(nil); entries != nil {
+		t.Errorf("Expected no entries for nil error, got %v", entries)
+	}
+}
+
+func TestRequestLogger_DoubleFlush(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+	logger.FlushIf(errors.New("test error"))
+	logger.Flush()
+
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("Expected a single flush of 2 lines, got %d lines", n)
+	}
+}
+
+func TestRequestLogger_Discard(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+
+	logger.Debug("debug message")
+	logger.Error("error message")
+	logger.Discard()
+
+	if buf.String() != "" {
+		t.Errorf("Expected no output after Discard, got %q", buf.String())
+	}
+	if len(logger.buf) != 0 || !logger.flushed {
+		t.Error("Expected discarded logger to be cleared and marked as flushed")
+	}
+
+	logger.Discard() // no-op on an already pooled logger
+
+	reused := FromContext(WithLogger(context.Background()))
+	if len(reused.buf) != 0 || reused.flushed || reused.w != os.Stderr {
+		t.Error("Expected a clean logger from the pool")
+	}
+	reused.Discard()
+}
+
 func TestRequestLogger_Flush(t *testing.T) {
 	var buf bytes.Buffer
 	logger := &requestLogger{
@@ -305,6 +533,58 @@ func TestRequestLogger_Flush(t *testing.T) {
 	}
 }
 
+func TestRequestLogger_LazyID(t *testing.T) {
+	logger := FromContext(WithLogger(context.Background()))
+
+	if logger.id != "" {
+		t.Errorf("Expected ID to be generated lazily, got '%s'", logger.id)
+	}
+
+	id := logger.ID()
+	if id == "" {
+		t.Fatal("Expected ID to be generated on first use")
+	}
+
+	var buf bytes.Buffer
+	logger.w = &buf
+	logger.Debug("first")
+	logger.write(nil, logger.buf, nil)
+	logger.Debug("second")
+	logger.write(nil, logger.buf, nil, Entry{Level: ErrorLevel, Message: "test error"})
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.HasPrefix(line, "["+id+"] ") {
+			t.Errorf("Expected line to carry ID %s, got '%s'", id, line)
+		}
+	}
+	if logger.ID() != id {
+		t.Errorf("Expected stable ID %s, got %s", id, logger.ID())
+	}
+
+	logger.FlushIf(nil)
+}
+
+func TestRequestLogger_FlushAndReset(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+	id := logger.ID()
+
+	logger.Debug("first message")
+	logger.FlushAndReset()
+	logger.Debug("second message")
+	logger.FlushAndReset()
+
+	expected := "[" + id + "] D: first message\n[" + id + "] D: second message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if logger.ID() != id {
+		t.Errorf("Expected stable ID %s, got %s", id, logger.ID())
+	}
+
+	logger.FlushIf(nil)
+}
+
 func TestRequestLogger_EmptyFlush(t *testing.T) {
 	var buf bytes.Buffer
 	logger := &requestLogger{
@@ -355,7 +635,7 @@ func TestPoolReuse(t *testing.T) {
 	logger1 := FromContext(ctx1)
 	logger1.Debug("test message")
 
-	id1 := logger1.id
+	id1 := logger1.ID()
 
 	logger1.FlushIf(nil)
 
@@ -366,11 +646,318 @@ func TestPoolReuse(t *testing.T) {
 		t.Errorf("Expected empty buffer from pool reuse, got %d entries", len(logger2.buf))
 	}
 
-	if id1 == logger2.id {
+	if id1 == logger2.ID() {
 		t.Error("Expected different IDs for different logger instances")
 	}
 }
 
+func TestRequestLogger_Detach(t *testing.T) {
+	logger := FromContext(WithLogger(context.Background()))
+	logger.Debug("debug message")
+	logger.Info("info message")
+
+	detached := logger.Detach()
+	id := logger.ID()
+	logger.FlushIf(nil)
+
+	reused := FromContext(WithLogger(context.Background()))
+	reused.Warn("other request")
+
+	if detached.ID() != id {
+		t.Errorf("Expected detached ID %s, got %s", id, detached.ID())
+	}
+	if len(detached.buf) != 2 {
+		t.Fatalf("Expected 2 entries in detached copy, got %d", len(detached.buf))
+	}
+	if detached.buf[0].message != "debug message" || detached.buf[1].message != "info message" {
+		t.Errorf("Expected original entries, got %v", detached.buf)
+	}
+
+	var buf bytes.Buffer
+	detached.w = &buf
+	detached.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "] D: debug message\n[" + id + "] I: info message\n[" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if len(detached.buf) != 0 || detached.ID() != id {
+		t.Error("Expected detached logger to clear its buffer and keep its ID after flush")
+	}
+
+	reused.FlushIf(nil)
+}
+
+func TestPoolReuse_ResetsWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger1 := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+	if logger1.w != &buf {
+		t.Fatal("Expected configured writer")
+	}
+	logger1.Debug("first request")
+	logger1.FlushIf(nil)
+
+	logger2 := FromContext(WithLogger(context.Background()))
+	if logger2.w != os.Stderr {
+		t.Error("Expected pooled logger to write to os.Stderr, got stale writer")
+	}
+	logger2.FlushIf(nil)
+
+	logger3 := FromContext(context.Background())
+	logger3.Flush()
+
+	logger4 := FromContext(WithLogger(context.Background()))
+	if logger4.w != os.Stderr {
+		t.Error("Expected noop logger's writer not to leak into the pool")
+	}
+	logger4.FlushIf(nil)
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithName("daemon"))
+	id := logger.ID()
+
+	logger.Debug("first cycle")
+	logger.Flush()
+	logger.Info("second cycle")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "][daemon] D: first cycle\n" +
+		"[" + id + "][daemon] I: second cycle\n" +
+		"[" + id + "][daemon] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// A pooled logger would have been reset on flush.
+	if logger.ID() != id || logger.w != &buf || logger.name != "daemon" {
+		t.Error("Expected standalone logger to keep its ID, writer and options")
+	}
+	if len(logger.buf) != 0 {
+		t.Errorf("Expected empty buffer after flush, got %d entries", len(logger.buf))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	helper := New(io.Discard)
+	helper.id = "helper-1"
+	named := New(io.Discard, WithName("db"))
+
+	logger.Debug("before")
+	helper.Info("helper step")
+	named.Warn("slow query")
+	logger.Merge(helper)
+	logger.Merge(named)
+	logger.Debug("after")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: before\n" +
+		"[test-123][helper-1] I: helper step\n" +
+		"[test-123][db] W: slow query\n" +
+		"[test-123] D: after\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if len(helper.buf) != 1 || helper.flushed {
+		t.Error("Expected merged logger to be left untouched")
+	}
+}
+
+func TestDrainTo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	ctx := WithLogger(context.Background(), WithName("worker"))
+	child := FromContext(ctx)
+
+	child.Debug("step one")
+	child.Info("step two")
+	child.DrainTo(logger)
+	child.DrainTo(logger)
+
+	if !child.Flushed() || len(child.buf) != 0 {
+		t.Error("Expected drained logger to be empty and pooled")
+	}
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123][worker] D: step one\n" +
+		"[test-123][worker] I: step two\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: io.Discard}
+	logger.Debug("debug message")
+	logger.Info("info message")
+
+	var wt io.WriterTo = logger
+	n, err := wt.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[test-123] D: debug message\n[test-123] I: info message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if n != int64(len(expected)) {
+		t.Errorf("Expected %d bytes, got %d", len(expected), n)
+	}
+	if len(logger.buf) != 2 || logger.flushed {
+		t.Error("Expected WriteTo to keep the buffer and the logger")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+	defer logger.FlushIf(nil)
+
+	if logger.Writer() != &buf || logger.Named("auth").Writer() != &buf {
+		t.Error("Expected the configured writer")
+	}
+	if w := FromContext(context.Background()).Writer(); w != io.Discard {
+		t.Errorf("Expected io.Discard for the noop logger, got %v", w)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+
+	logger.Debug("debug message")
+	logger.Peek()
+	if expected := "[test-123] D: debug message\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if len(logger.buf) != 1 || logger.flushed {
+		t.Error("Expected Peek to keep the buffer and the logger")
+	}
+
+	buf.Reset()
+	logger.Info("info message")
+	logger.Flush()
+	expected := "[test-123] D: debug message\n[test-123] I: info message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRange(t *testing.T) {
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: io.Discard}
+	logger.Debug("debug message")
+	logger.Named("auth").Info("info message")
+	logger.Warn("warn message")
+
+	var got []Entry
+	logger.Range(func(e Entry) bool {
+		got = append(got, e)
+		return true
+	})
+
+	expected := []Entry{
+		{Level: DebugLevel, Message: "debug message"},
+		{Level: InfoLevel, Message: "info message", Name: "auth"},
+		{Level: WarnLevel, Message: "warn message"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(got))
+	}
+	for i, e := range expected {
+		if got[i] != e {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, got[i])
+		}
+	}
+}
+
+func TestRange_StopsEarly(t *testing.T) {
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: io.Discard}
+	for i := 0; i < 5; i++ {
+		logger.Debugf("step %d", i)
+	}
+
+	calls := 0
+	logger.Range(func(e Entry) bool {
+		calls++
+		return e.Message != "step 1"
+	})
+
+	if calls != 2 {
+		t.Errorf("Expected Range to stop after 2 entries, got %d calls", calls)
+	}
+}
+
+func TestReset(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithName("daemon"))
+	logger.fields = append(logger.fields, field{key: "user", value: "42"})
+	id := logger.ID()
+
+	logger.Debug("debug message")
+	logger.Reset()
+
+	if len(logger.buf) != 0 {
+		t.Errorf("Expected empty buffer after Reset, got %d entries", len(logger.buf))
+	}
+	if len(logger.fields) != 0 {
+		t.Errorf("Expected no fields after Reset, got %d", len(logger.fields))
+	}
+	if logger.ID() == id {
+		t.Errorf("Expected a new ID after Reset, got %q again", id)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written by Reset, got %q", buf.String())
+	}
+	if logger.w != &buf || logger.name != "daemon" {
+		t.Error("Expected Reset to keep the writer and options")
+	}
+}
+
+func TestReset_KeepsOptionFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithServiceFields("billing", "prod", "1.2.3"), WithIDScheme(fixedID))
+	logger.fields = append(logger.fields, field{key: "user", value: "42"})
+
+	logger.Reset()
+	logger.Info("info message")
+	logger.Flush()
+
+	expected := "[test] I: info message service=billing env=prod version=1.2.3\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPoolReuse_BoundsBufferCapacity(t *testing.T) {
+	logger := FromContext(WithLogger(context.Background()))
+	for i := 0; i < 10000; i++ {
+		logger.Debug("debug message")
+	}
+	logger.FlushIf(nil)
+
+	if max := maxPooledGrowth * int(bufCap.Load()); cap(logger.buf) > max {
+		t.Errorf("Expected pooled buffer capacity at most %d, got %d", max, cap(logger.buf))
+	}
+
+	logger = FromContext(WithLogger(context.Background()))
+	for i := 0; i < 2*int(bufCap.Load()); i++ {
+		logger.Debug("debug message")
+	}
+	grown := cap(logger.buf)
+	logger.FlushIf(nil)
+
+	if cap(logger.buf) != grown {
+		t.Errorf("Expected moderately grown buffer to be kept, got capacity %d instead of %d", cap(logger.buf), grown)
+	}
+}
+
 func TestConcurrentUsage(t *testing.T) {
 	var wg sync.WaitGroup
 	const numGoroutines = 100
@@ -424,8 +1011,8 @@ func TestRequestLogger_FlushWithWriteError(t *testing.T) {
 
 	logger.Flush()
 
-	if fw.callCount != 2 {
-		t.Errorf("Expected 2 write calls, got %d", fw.callCount)
+	if fw.callCount != 1 { // entries are batched into a single write
+		t.Errorf("Expected 1 write call, got %d", fw.callCount)
 	}
 }
 
@@ -443,8 +1030,39 @@ func TestRequestLogger_FlushIfWithWriteError(t *testing.T) {
 	// Should not panic even with write errors
 	logger.FlushIf(errors.New("test error"))
 
-	if fw.callCount != 3 { // 2 buffered entries + 1 error
-		t.Errorf("Expected 3 write calls, got %d", fw.callCount)
+	if fw.callCount != 1 { // 2 buffered entries + 1 error in a single write
+		t.Errorf("Expected 1 write call, got %d", fw.callCount)
+	}
+}
+
+type readerFromWriter struct {
+	bytes.Buffer
+	readFromCalls int
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFromCalls++
+	return w.Buffer.ReadFrom(r)
+}
+
+func TestRequestLogger_FlushToReaderFrom(t *testing.T) {
+	w := &readerFromWriter{}
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   w,
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: debug message\n[test-123] I: info message\n[test-123] E: test error\n"
+	if w.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, w.String())
+	}
+	if w.readFromCalls != 1 {
+		t.Errorf("Expected 1 ReadFrom call, got %d", w.readFromCalls)
 	}
 }
 
@@ -610,6 +1228,37 @@ func BenchmarkRequestLogger_Flush(b *testing.B) {
 	}
 }
 
+// BenchmarkRequestLogger_FlushWriter compares flushing to a plain io.Writer
+// against an io.ReaderFrom destination
+func BenchmarkRequestLogger_FlushWriter(b *testing.B) {
+	writers := map[string]io.Writer{
+		"Writer":     struct{ io.Writer }{io.Discard},
+		"ReaderFrom": &readerFromWriter{},
+	}
+
+	for name, w := range writers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger := &requestLogger{
+					id:       "bench-test",
+					buf:      make([]logEntry, 0, 32),
+					w:        w,
+					detached: true,
+				}
+				logger.Debug("debug message")
+				logger.Info("info message")
+				logger.Warn("warn message")
+				logger.Flush()
+
+				if rf, ok := w.(*readerFromWriter); ok {
+					rf.Reset()
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkWithLogger benchmarks context logger creation
 func BenchmarkWithLogger(b *testing.B) {
 	ctx := context.Background()
@@ -620,6 +1269,18 @@ func BenchmarkWithLogger(b *testing.B) {
 	}
 }
 
+// BenchmarkWithLogger_Pooled benchmarks a pooled logger round trip on the
+// success path, which must not generate a request ID
+func BenchmarkWithLogger_Pooled(b *testing.B) {
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FromContext(WithLogger(ctx)).FlushIf(nil)
+	}
+}
+
 // BenchmarkFromContext benchmarks logger retrieval from context
 func BenchmarkFromContext(b *testing.B) {
 	ctx := WithLogger(context.Background())
@@ -677,6 +1338,153 @@ func BenchmarkFullWorkflow_WithoutError(b *testing.B) {
 	}
 }
 
+// fullEntry is the layout of logEntry with its name, time and sequence
+// number stored inline instead of in the side storage of the logger.
+type fullEntry struct {
+	level   Level
+	tag     uint16
+	message string
+	name    string
+	time    time.Time
+	seq     uint32
+}
+
+// TestEntryLayoutParity checks that entries whose time and sequence number
+// live in the side slice read and render exactly like entries storing them
+// inline, also after the buffer is copied, merged or evicted from.
+func TestEntryLayoutParity(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)}
+	var buf bytes.Buffer
+	logger := New(&buf, WithClock(clock), WithSequence(), WithTimeFormat(time.RFC3339Nano), WithMaxBytes(40))
+	logger.id = "test-123"
+
+	var inline []fullEntry
+	for i := range 6 {
+		clock.t = clock.t.Add(time.Duration(i) * time.Millisecond)
+		msg := fmt.Sprintf("message %d", i)
+		logger.Info(msg)
+		inline = append(inline, fullEntry{level: InfoLevel, message: msg, time: clock.t, seq: uint32(i + 1)})
+	}
+	// 6 messages of 9 bytes: the first two were evicted.
+	inline = inline[2:]
+
+	want := make([]Entry, len(inline))
+	for i, e := range inline {
+		want[i] = Entry{Level: e.level, Message: e.message, Name: e.name, Time: e.time, Seq: e.seq}
+	}
+	collect := func(l *requestLogger) []Entry {
+		var got []Entry
+		l.Range(func(e Entry) bool {
+			got = append(got, e)
+			return true
+		})
+		return got
+	}
+	if got := collect(logger); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := collect(logger.Detach()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the detached copy to read %+v, got %+v", want, got)
+	}
+	merged := New(io.Discard)
+	merged.Debug("before")
+	merged.Merge(logger)
+	got := collect(merged)[1:]
+	for i := range want {
+		want[i].Name = "test-123"
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the merged entries %+v, got %+v", want, got)
+	}
+
+	var expected bytes.Buffer
+	for _, e := range want {
+		e.Name = ""
+		logger.writeLine(&expected, "test-123", nil, e)
+	}
+	logger.Flush()
+	if buf.String() != expected.String() {
+		t.Errorf("Expected %q, got %q", expected.String(), buf.String())
+	}
+}
+
+// TestEntryNames checks that the component names of entries, kept in the
+// names table of their logger, read back unchanged, also past the 255
+// names that fit in an entry and after the buffer is copied or merged.
+func TestEntryNames(t *testing.T) {
+	if size := unsafe.Sizeof(logEntry{}); size != 24 {
+		t.Errorf("Expected a 24-byte entry, got %d bytes", size)
+	}
+
+	logger := New(io.Discard)
+	var want []Entry
+	for i := range 300 {
+		name := fmt.Sprintf("component%d", i%260)
+		logger.Named(name).Info("message")
+		want = append(want, Entry{Level: InfoLevel, Message: "message", Name: name})
+	}
+	logger.Info("unnamed")
+	want = append(want, Entry{Level: InfoLevel, Message: "unnamed"})
+	if n := len(logger.names); n != 260 {
+		t.Errorf("Expected 260 names in the table, got %d", n)
+	}
+
+	collect := func(l *requestLogger) []Entry {
+		var got []Entry
+		l.Range(func(e Entry) bool {
+			got = append(got, e)
+			return true
+		})
+		return got
+	}
+	if got := collect(logger); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := collect(logger.Detach()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the detached copy to read %+v, got %+v", want, got)
+	}
+
+	merged := New(io.Discard)
+	merged.Named("handler").Info("first")
+	merged.Merge(logger)
+	want[len(want)-1].Name = logger.ID()
+	want = append([]Entry{{Level: InfoLevel, Message: "first", Name: "handler"}}, want...)
+	if got := collect(merged); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the merged entries %+v, got %+v", want, got)
+	}
+}
+
+// BenchmarkEntryLayout benchmarks the growth workload of BenchmarkMemoryGrowth
+// with the compact entry layout against one storing the details inline.
+func BenchmarkEntryLayout(b *testing.B) {
+	b.Run("Compact", func(b *testing.B) {
+		buf := make([]logEntry, 0, 32)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, l := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+				buf = append(buf, logEntry{level: l, message: "message"})
+			}
+			if i%100 == 0 {
+				buf = buf[:0]
+			}
+		}
+		b.ReportMetric(float64(unsafe.Sizeof(logEntry{})), "B/entry")
+	})
+	b.Run("Full", func(b *testing.B) {
+		buf := make([]fullEntry, 0, 32)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, l := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+				buf = append(buf, fullEntry{level: l, message: "message"})
+			}
+			if i%100 == 0 {
+				buf = buf[:0]
+			}
+		}
+		b.ReportMetric(float64(unsafe.Sizeof(fullEntry{})), "B/entry")
+	})
+}
+
 // BenchmarkMemoryGrowth benchmarks buffer growth behavior
 func BenchmarkMemoryGrowth(b *testing.B) {
 	logger := &requestLogger{
@@ -744,6 +1552,25 @@ func BenchmarkPoolReuse(b *testing.B) {
 	}
 }
 
+// BenchmarkOversizedRequests measures the heap retained by the pool after a
+// few requests with huge buffers
+func BenchmarkOversizedRequests(b *testing.B) {
+	var m runtime.MemStats
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 4; j++ {
+			logger := FromContext(WithLogger(context.Background()))
+			for k := 0; k < 100000; k++ {
+				logger.Debug("debug message")
+			}
+			logger.FlushIf(nil)
+		}
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	b.ReportMetric(float64(m.HeapInuse), "heap-inuse-bytes")
+}
+
 // BenchmarkStringFormatting benchmarks string formatting overhead
 func BenchmarkStringFormatting(b *testing.B) {
 	logger := &requestLogger{
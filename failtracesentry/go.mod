@@ -0,0 +1,16 @@
+module github.com/IbrahimShahzad/failtrace/failtracesentry
+
+go 1.24.3
+
+require (
+	github.com/IbrahimShahzad/failtrace v0.0.0-00010101000000-000000000000
+	github.com/getsentry/sentry-go v0.35.3
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace github.com/IbrahimShahzad/failtrace => ../
@@ -0,0 +1,56 @@
+// Package failtracesentry reports failtrace flushes to Sentry: the buffered
+// entries are attached as breadcrumbs and the flushed error is captured as an
+// exception.
+//
+// Usage:
+//
+//	ctx = failtrace.WithLogger(ctx, failtracesentry.Reporter(sentry.CurrentHub()))
+package failtracesentry
+
+import (
+	"github.com/IbrahimShahzad/failtrace"
+	"github.com/getsentry/sentry-go"
+)
+
+// Hub is the subset of *sentry.Hub used by the reporter.
+type Hub interface {
+	AddBreadcrumb(breadcrumb *sentry.Breadcrumb, hint *sentry.BreadcrumbHint)
+	CaptureException(exception error) *sentry.EventID
+}
+
+var _ Hub = (*sentry.Hub)(nil)
+
+// Reporter returns an option that, on FlushIf with a non-nil error, adds each
+// buffered entry to hub as a breadcrumb and captures the error. Flushes
+// without an error are ignored.
+func Reporter(hub Hub) failtrace.Option {
+	return failtrace.OnFlush(func(info failtrace.FlushInfo) {
+		if info.Err == nil {
+			return
+		}
+
+		for _, e := range info.Buffered {
+			hub.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: e.Name,
+				Message:  e.Message,
+				Level:    level(e.Level),
+				Data:     map[string]any{"request_id": info.ID},
+			}, nil)
+		}
+		hub.CaptureException(info.Err)
+	})
+}
+
+// level maps a failtrace level to a sentry level.
+func level(l failtrace.Level) sentry.Level {
+	switch l {
+	case failtrace.DebugLevel:
+		return sentry.LevelDebug
+	case failtrace.InfoLevel:
+		return sentry.LevelInfo
+	case failtrace.WarnLevel:
+		return sentry.LevelWarning
+	default:
+		return sentry.LevelError
+	}
+}
@@ -0,0 +1,61 @@
+//go:build !failtrace_disabled
+
+package failtracesentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IbrahimShahzad/failtrace"
+	"github.com/getsentry/sentry-go"
+)
+
+type fakeHub struct {
+	breadcrumbs []*sentry.Breadcrumb
+	exceptions  []error
+}
+
+func (h *fakeHub) AddBreadcrumb(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) {
+	h.breadcrumbs = append(h.breadcrumbs, breadcrumb)
+}
+
+func (h *fakeHub) CaptureException(exception error) *sentry.EventID {
+	h.exceptions = append(h.exceptions, exception)
+	return nil
+}
+
+func TestReporter_WithError(t *testing.T) {
+	hub := &fakeHub{}
+	logger := failtrace.FromContext(failtrace.WithLogger(context.Background(), Reporter(hub)))
+
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+	testErr := errors.New("test error")
+	logger.FlushIf(testErr)
+
+	if len(hub.breadcrumbs) != 2 {
+		t.Fatalf("Expected 2 breadcrumbs, got %d", len(hub.breadcrumbs))
+	}
+	if hub.breadcrumbs[0].Message != "debug message" || hub.breadcrumbs[0].Level != sentry.LevelDebug {
+		t.Errorf("Unexpected first breadcrumb: %+v", hub.breadcrumbs[0])
+	}
+	if hub.breadcrumbs[1].Message != "warn message" || hub.breadcrumbs[1].Level != sentry.LevelWarning {
+		t.Errorf("Unexpected second breadcrumb: %+v", hub.breadcrumbs[1])
+	}
+	if len(hub.exceptions) != 1 || hub.exceptions[0] != testErr {
+		t.Errorf("Expected one captured exception, got %v", hub.exceptions)
+	}
+}
+
+func TestReporter_NoError(t *testing.T) {
+	hub := &fakeHub{}
+	logger := failtrace.FromContext(failtrace.WithLogger(context.Background(), Reporter(hub)))
+
+	logger.Debug("debug message")
+	logger.FlushIf(nil)
+
+	if len(hub.breadcrumbs) != 0 || len(hub.exceptions) != 0 {
+		t.Errorf("Expected nothing reported, got %d breadcrumbs and %d exceptions", len(hub.breadcrumbs), len(hub.exceptions))
+	}
+}
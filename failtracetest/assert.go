@@ -0,0 +1,65 @@
+// Package failtracetest provides assertions on failtrace output captured in
+// tests, e.g. by passing a bytes.Buffer to failtrace.WithWriter.
+//
+// Usage:
+//
+//	var buf bytes.Buffer
+//	ctx := failtrace.WithLogger(ctx, failtrace.WithWriter(&buf))
+//	handle(ctx)
+//	failtracetest.AssertLogged(t, &buf, failtrace.WarnLevel, "retrying")
+package failtracetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IbrahimShahzad/failtrace"
+)
+
+// Output is captured output, such as a *bytes.Buffer or *strings.Builder.
+type Output interface {
+	String() string
+}
+
+// AssertLogged fails the test unless out holds a line at level whose message
+// contains substring. The failure lists the captured lines.
+func AssertLogged(t testing.TB, out Output, level failtrace.Level, substring string) {
+	t.Helper()
+	if !logged(out.String(), level, substring) {
+		t.Errorf("expected a %s entry containing %q, got:\n%s", level, substring, describe(out.String()))
+	}
+}
+
+// AssertNotLogged fails the test if out holds a line at level whose message
+// contains substring.
+func AssertNotLogged(t testing.TB, out Output, level failtrace.Level, substring string) {
+	t.Helper()
+	if logged(out.String(), level, substring) {
+		t.Errorf("expected no %s entry containing %q, got:\n%s", level, substring, describe(out.String()))
+	}
+}
+
+// logged reports whether output holds a matching line in the default format.
+func logged(output string, level failtrace.Level, substring string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		e, _, ok := failtrace.ParseLine(line)
+		if ok && e.Level == level && strings.Contains(e.Message, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe renders the captured output for failure messages.
+func describe(output string) string {
+	output = strings.TrimSuffix(output, "\n")
+	if output == "" {
+		return "\t(no output)"
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Fprintf(&b, "\t%s\n", line)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,69 @@
+//go:build !failtrace_disabled
+
+package failtracetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IbrahimShahzad/failtrace"
+)
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func capture() *bytes.Buffer {
+	var buf bytes.Buffer
+	logger := failtrace.New(&buf)
+	logger.Warn("retrying: timeout")
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+	return &buf
+}
+
+func TestAssertLogged(t *testing.T) {
+	buf := capture()
+
+	tb := &fakeTB{}
+	AssertLogged(tb, buf, failtrace.WarnLevel, "retrying")
+	AssertLogged(tb, buf, failtrace.ErrorLevel, "test error")
+	if len(tb.errors) != 0 {
+		t.Errorf("Expected no failures, got %q", tb.errors)
+	}
+
+	AssertLogged(tb, buf, failtrace.InfoLevel, "retrying")
+	if len(tb.errors) != 1 {
+		t.Fatalf("Expected 1 failure, got %d", len(tb.errors))
+	}
+	if !strings.Contains(tb.errors[0], `expected a info entry containing "retrying"`) ||
+		!strings.Contains(tb.errors[0], "W: retrying: timeout") {
+		t.Errorf("Expected the failure to list the captured lines, got %q", tb.errors[0])
+	}
+}
+
+func TestAssertNotLogged(t *testing.T) {
+	buf := capture()
+
+	tb := &fakeTB{}
+	AssertNotLogged(tb, buf, failtrace.ErrorLevel, "retrying")
+	if len(tb.errors) != 0 {
+		t.Errorf("Expected no failures, got %q", tb.errors)
+	}
+
+	AssertNotLogged(tb, buf, failtrace.DebugLevel, "debug")
+	if len(tb.errors) != 1 {
+		t.Errorf("Expected 1 failure, got %d", len(tb.errors))
+	}
+}
@@ -0,0 +1,115 @@
+// Package failtracezap provides a zapcore.Core that buffers zap entries into
+// the failtrace logger of a context, so teams on zap keep their structured
+// fields while only writing traces of failed requests.
+//
+// Usage:
+//
+//	ctx = failtrace.WithLogger(ctx)
+//	log := zap.New(failtracezap.NewCore(ctx))
+//	defer log.Sync()
+package failtracezap
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/IbrahimShahzad/failtrace"
+	"go.uber.org/zap/zapcore"
+)
+
+// state is shared by a core and the cores derived from it by With.
+type state struct {
+	failed bool
+}
+
+type core struct {
+	ctx    context.Context
+	fields []zapcore.Field
+	state  *state
+}
+
+// NewCore returns a core buffering every entry, with its fields rendered as
+// " key=value", into the failtrace logger stored in ctx. Sync writes the
+// buffered trace if an entry at error level or above was logged through the
+// core and discards it otherwise, returning the logger to the pool either way.
+// Entries above error level, from DPanic, Panic and Fatal, write the trace at
+// once, before zap panics or exits.
+// Like the failtrace logger, the core must not be shared between goroutines.
+func NewCore(ctx context.Context) zapcore.Core {
+	return &core{ctx: ctx, state: &state{}}
+}
+
+// Enabled buffers every level; failtrace decides what is written on flush.
+func (c *core) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{
+		ctx:    c.ctx,
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		state:  c.state,
+	}
+}
+
+// Check always adds the core, so no entry is dropped before it is buffered.
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var sb strings.Builder
+	sb.WriteString(ent.Message)
+	writeFields(&sb, c.fields)
+	writeFields(&sb, fields)
+
+	log := failtrace.FromContext(c.ctx)
+	if ent.LoggerName != "" {
+		log = log.Named(ent.LoggerName)
+	}
+
+	msg := sb.String()
+	switch {
+	case ent.Level < zapcore.InfoLevel:
+		log.Debug(msg)
+	case ent.Level == zapcore.InfoLevel:
+		log.Info(msg)
+	case ent.Level == zapcore.WarnLevel:
+		log.Warn(msg)
+	default:
+		log.Error(msg)
+		c.state.failed = true
+	}
+	// zap runs the Panic and Fatal hooks without syncing first, so write the
+	// trace now, as zapcore's own cores do.
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+	return nil
+}
+
+func (c *core) Sync() error {
+	log := failtrace.FromContext(c.ctx)
+	if c.state.failed {
+		log.Flush()
+	} else {
+		log.Discard()
+	}
+	c.state.failed = false
+	return nil
+}
+
+// writeFields renders fields as " key=value" pairs, in order. A field that
+// adds several keys, such as zap.Inline, renders them sorted by key.
+func writeFields(sb *strings.Builder, fields []zapcore.Field) {
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		for _, k := range slices.Sorted(maps.Keys(enc.Fields)) {
+			fmt.Fprintf(sb, " %s=%v", k, enc.Fields[k])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//go:build !failtrace_disabled
+
+package failtracezap
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/IbrahimShahzad/failtrace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCore_FlushOnError(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := failtrace.WithLogger(context.Background(), failtrace.WithWriter(&buf))
+	id := failtrace.FromContext(ctx).ID()
+
+	log := zap.New(NewCore(ctx)).With(zap.String("tenant", "acme"))
+	log.Debug("loading order", zap.Int("order", 1234))
+	log.Named("db").Error("query failed")
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected entries to be buffered until Sync, got %q", buf.String())
+	}
+
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[" + id + "] D: loading order tenant=acme order=1234\n" +
+		"[" + id + "][db] E: query failed tenant=acme\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestCore_DiscardWithoutError(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := failtrace.WithLogger(context.Background(), failtrace.WithWriter(&buf))
+
+	log := zap.New(NewCore(ctx))
+	log.Info("handling request")
+	log.Warn("slow dependency")
+
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(buf.String()) != "" {
+		t.Errorf("Expected no output without errors, got %q", buf.String())
+	}
+}
+
+type request struct{}
+
+func (request) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", "GET")
+	enc.AddString("path", "/orders")
+	enc.AddInt("attempt", 2)
+	enc.AddString("host", "api")
+	return nil
+}
+
+func TestCore_InlineFieldsSorted(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := failtrace.WithLogger(context.Background(), failtrace.WithWriter(&buf))
+	id := failtrace.FromContext(ctx).ID()
+
+	log := zap.New(NewCore(ctx))
+	for range 10 {
+		log.Error("request failed", zap.Inline(request{}))
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Repeat("["+id+"] E: request failed attempt=2 host=api method=GET path=/orders\n", 10)
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// fatalHook records the output when zap runs the fatal hook, instead of
+// exiting.
+type fatalHook struct {
+	buf    *bytes.Buffer
+	output string
+}
+
+func (h *fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.output = h.buf.String()
+}
+
+func TestCore_FlushOnFatal(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := failtrace.WithLogger(context.Background(), failtrace.WithWriter(&buf))
+	id := failtrace.FromContext(ctx).ID()
+
+	hook := &fatalHook{buf: &buf}
+	log := zap.New(NewCore(ctx), zap.WithFatalHook(hook))
+	log.Debug("loading order")
+	log.Fatal("out of disk")
+
+	expected := "[" + id + "] D: loading order\n" +
+		"[" + id + "] E: out of disk\n"
+	if hook.output != expected {
+		t.Errorf("Expected %q before the fatal hook, got %q", expected, hook.output)
+	}
+}
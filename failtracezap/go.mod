@@ -0,0 +1,15 @@
+module github.com/IbrahimShahzad/failtrace/failtracezap
+
+go 1.24.3
+
+require (
+	github.com/IbrahimShahzad/failtrace v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
+
+replace github.com/IbrahimShahzad/failtrace => ../
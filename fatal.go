@@ -0,0 +1,28 @@
+package failtrace
+
+import (
+	"fmt"
+	"os"
+)
+
+// exit terminates the process. Tests replace it to observe the exit code.
+var exit = os.Exit
+
+// FatalCode logs msg at FatalLevel, writes the whole buffer as Flush does and
+// exits the process with code, so CLI tools can map failure classes to exit
+// codes. Codes outside 1–125 are reserved by shells and replaced by 1, with a
+// warning in the trace.
+//
+// Usage example:
+//
+//	log.FatalCode(3, "config file not found")
+func (l *requestLogger) FatalCode(code int, msg string) {
+	if code < 1 || code > 125 {
+		l.log(WarnLevel, fmt.Sprintf("invalid exit code %d, using 1", code))
+		code = 1
+	}
+	l.log(FatalLevel, msg)
+	// The process exits, so write the trace even from an inherited scope.
+	l.owner().Flush()
+	exit(code)
+}
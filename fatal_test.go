@@ -0,0 +1,46 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestFatalCode(t *testing.T) {
+	defer func() { exit = os.Exit }()
+
+	tests := []struct {
+		code     int
+		expected int
+		output   string
+	}{
+		{3, 3, "[test-123] D: debug message\n[test-123] F: config missing\n"},
+		{200, 1, "[test-123] D: debug message\n[test-123] W: invalid exit code 200, using 1\n[test-123] F: config missing\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := New(&buf)
+		logger.id = "test-123"
+
+		got := -1
+		exit = func(code int) {
+			if buf.Len() == 0 {
+				t.Error("Expected the buffer to be flushed before exiting")
+			}
+			got = code
+		}
+
+		logger.Debug("debug message")
+		logger.FatalCode(tt.code, "config missing")
+
+		if got != tt.expected {
+			t.Errorf("FatalCode(%d): expected exit code %d, got %d", tt.code, tt.expected, got)
+		}
+		if buf.String() != tt.output {
+			t.Errorf("FatalCode(%d): expected %q, got %q", tt.code, tt.output, buf.String())
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// field is a persistent key/value pair rendered on every flushed line.
+type field struct {
+	key   string
+	value string
+}
+
+// WithContextValues copies the values stored under the given context keys
+// into persistent fields when the logger is installed by WithLogger, so they
+// are rendered as " key=value" on every flushed line. Keys missing from the
+// context are skipped.
+//
+//	ctx = failtrace.WithLogger(ctx, failtrace.WithContextValues(userIDKey, tenantKey))
+func WithContextValues(keys ...any) Option {
+	return func(l *requestLogger) {
+		l.ctxKeys = append(l.ctxKeys, keys...)
+	}
+}
+
+// seed stores the values of the configured context keys as fields.
+func (l *requestLogger) seed(ctx context.Context) {
+	for _, k := range l.ctxKeys {
+		if v := ctx.Value(k); v != nil {
+			l.fields = append(l.fields, field{key: fmt.Sprint(k), value: fmt.Sprint(v)})
+		}
+	}
+}
+
+// AddField attaches a persistent field to the logger stored in ctx, rendered
+// as " key=value" on every line it flushes. It is a no-op if the context
+// carries no logger. FromContext returns the same logger, so fields added here
+// are visible to every function sharing the context.
+func AddField(ctx context.Context, key string, value any) {
+	if l, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		o := l.owner()
+		o.fields = append(o.fields, field{key: key, value: fmt.Sprint(value)})
+	}
+}
+
+// WithServiceFields adds the standard service, env and version fields to
+// every flushed line, as " service=... env=... version=..." in text mode and
+// as top-level keys in JSON mode. The fields are built once, so the option is
+// meant to be created at startup and reused, e.g. through WithDefaults.
+//
+//	ctx = failtrace.WithDefaults(ctx, failtrace.WithServiceFields("billing", "prod", version))
+func WithServiceFields(service, env, version string) Option {
+	fields := []field{{"service", service}, {"env", env}, {"version", version}}
+	return func(l *requestLogger) {
+		l.fields = append(l.fields, fields...)
+	}
+}
+
+// fielder is implemented by errors carrying structured fields.
+type fielder interface {
+	Fields() map[string]any
+}
+
+// errFields renders the fields of err, if it or an error it wraps implements
+// Fields() map[string]any, as " key=value" pairs sorted by key.
+func errFields(err error) string {
+	var fe fielder
+	if !errors.As(err, &fe) {
+		return ""
+	}
+	fields := fe.Fields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	return b.String()
+}
+
+// With returns a child scope of the logger whose entries carry the given
+// key/value pairs, rendered as " key=value" after the message in the order
+// given. Like Named, the child writes into the same buffer; fields of nested
+// scopes accumulate.
+//
+// Usage example:
+//
+//	log := failtrace.FromContext(ctx).With("order_id", id, "attempt", n)
+//	log.Debug("charging card") // [id] D: charging card order_id=42 attempt=1
+func (l *requestLogger) With(keysAndValues ...any) *requestLogger {
+	return &requestLogger{
+		w:         l.w,
+		name:      l.name,
+		with:      append(l.with[:len(l.with):len(l.with)], pairs(keysAndValues)...),
+		tag:       l.tag,
+		root:      l.owner(),
+		inherited: l.inherited,
+	}
+}
+
+// Debugw logs a debug-level message followed by the given key/value pairs,
+// rendered as " key=value" in the order given.
+func (l *requestLogger) Debugw(msg string, keysAndValues ...any) {
+	if disabled {
+		return
+	}
+	l.log(DebugLevel, appendFields(msg, pairs(keysAndValues)))
+}
+
+// Infow logs an info-level message with key/value pairs, like Debugw.
+func (l *requestLogger) Infow(msg string, keysAndValues ...any) {
+	if disabled {
+		return
+	}
+	l.log(InfoLevel, appendFields(msg, pairs(keysAndValues)))
+}
+
+// Warnw logs a warn-level message with key/value pairs, like Debugw.
+func (l *requestLogger) Warnw(msg string, keysAndValues ...any) {
+	if disabled {
+		return
+	}
+	l.log(WarnLevel, appendFields(msg, pairs(keysAndValues)))
+}
+
+// Errorw logs an error-level message with key/value pairs, like Debugw.
+func (l *requestLogger) Errorw(msg string, keysAndValues ...any) {
+	if disabled {
+		return
+	}
+	l.log(ErrorLevel, appendFields(msg, pairs(keysAndValues)))
+}
+
+// pairs converts alternating keys and values into fields, keeping their
+// order. A trailing key without a value gets the value "(MISSING)".
+func pairs(keysAndValues []any) []field {
+	fields := make([]field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		f := field{key: fmt.Sprint(keysAndValues[i]), value: "(MISSING)"}
+		if i+1 < len(keysAndValues) {
+			f.value = fmt.Sprint(keysAndValues[i+1])
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// appendFields renders fields after msg as " key=value" pairs.
+func appendFields(msg string, fields []field) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		b.WriteString(f.value)
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type testCtxKey string
+
+func TestWithContextValues(t *testing.T) {
+	ctx := context.WithValue(context.Background(), testCtxKey("user_id"), 42)
+	ctx = context.WithValue(ctx, testCtxKey("tenant"), "acme")
+
+	var buf bytes.Buffer
+	ctx = WithLogger(ctx, WithWriter(&buf), WithContextValues(testCtxKey("user_id"), testCtxKey("missing"), testCtxKey("tenant")))
+	logger := FromContext(ctx)
+	id := logger.ID()
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "] I: info message user_id=42 tenant=acme\n" +
+		"[" + id + "] E: test error user_id=42 tenant=acme\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestAddField(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), WithWriter(&buf))
+	logger := FromContext(ctx)
+	id := logger.ID()
+
+	logger.Debug("before field")
+	AddField(ctx, "order", 1234)
+	FromContext(ctx).Info("after field")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "] D: before field order=1234\n" +
+		"[" + id + "] I: after field order=1234\n" +
+		"[" + id + "] E: test error order=1234\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestAddField_NoLogger(t *testing.T) {
+	ctx := context.Background()
+	AddField(ctx, "order", 1234)
+
+	if logger := FromContext(ctx); len(logger.fields) != 0 {
+		t.Errorf("Expected no fields on noop logger, got %v", logger.fields)
+	}
+}
+
+type fieldsError struct{}
+
+func (fieldsError) Error() string { return "order failed" }
+
+func (fieldsError) Fields() map[string]any {
+	return map[string]any{"order_id": 17, "amount": 9.5}
+}
+
+func TestFlushIfErrorFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Info("info message")
+	logger.FlushIf(fieldsError{})
+
+	expected := "[test-123] I: info message\n[test-123] E: order failed amount=9.5 order_id=17\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWith_OrderedFields(t *testing.T) {
+	for run := 0; run < 20; run++ {
+		var buf bytes.Buffer
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+
+		scoped := logger.With("zeta", 1, "alpha", 2).With("mid", "x")
+		scoped.Debug("debug message")
+		scoped.Named("db").Infow("query", "table", "users", "rows", 3, "dangling")
+		logger.Warnw("warn message", "b", true, "a", nil)
+		logger.FlushIf(errors.New("test error"))
+
+		expected := "[test-123] D: debug message zeta=1 alpha=2 mid=x\n" +
+			"[test-123][db] I: query table=users rows=3 dangling=(MISSING) zeta=1 alpha=2 mid=x\n" +
+			"[test-123] W: warn message b=true a=<nil>\n" +
+			"[test-123] E: test error\n"
+		if buf.String() != expected {
+			t.Fatalf("Run %d: expected %q, got %q", run, expected, buf.String())
+		}
+	}
+}
+
+func TestWithServiceFields(t *testing.T) {
+	opt := WithServiceFields("billing", "prod", "1.2.3")
+
+	var buf bytes.Buffer
+	logger := New(&buf, opt)
+	logger.id = "test-123"
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] I: info message service=billing env=prod version=1.2.3\n" +
+		"[test-123] E: test error service=billing env=prod version=1.2.3\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	logger = New(&buf, opt, WithJSON())
+	logger.id = "test-123"
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected = `{"id":"test-123","level":"I","message":"info message","service":"billing","env":"prod","version":"1.2.3"}` + "\n" +
+		`{"id":"test-123","level":"E","message":"test error","service":"billing","env":"prod","version":"1.2.3"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
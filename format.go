@@ -0,0 +1,53 @@
+package failtrace
+
+import "io"
+
+// Format selects how a flush is rendered for a writer added by
+// WithFormatWriter.
+type Format byte
+
+const (
+	// TextFormat renders "[id] L: message" lines.
+	TextFormat Format = iota
+	// JSONFormat renders one JSON object per line, as WithJSON does.
+	JSONFormat
+)
+
+// output is a writer added by WithFormatWriter.
+type output struct {
+	format Format
+	w      io.Writer
+}
+
+// WithFormatWriter renders every flush in format to w, e.g. text to stderr and
+// JSON to a file for ingestion. It can be repeated to add writers; once it is
+// used, flushes go to the added writers only and not to the writer set by
+// WithWriter.
+//
+//	ctx = failtrace.WithLogger(ctx,
+//		failtrace.WithFormatWriter(failtrace.TextFormat, os.Stderr),
+//		failtrace.WithFormatWriter(failtrace.JSONFormat, file))
+func WithFormatWriter(format Format, w io.Writer) Option {
+	return func(l *requestLogger) {
+		l.outputs = append(l.outputs, output{format: format, w: w})
+	}
+}
+
+// writeOutputs renders the entries once per added writer. It returns the
+// total number of bytes written and the first write error.
+func (l *requestLogger) writeOutputs(err error, entries []logEntry, lead []Entry, trail ...Entry) (int, error) {
+	var (
+		total    int
+		firstErr error
+	)
+	for _, o := range l.outputs {
+		c := *l
+		c.w, c.json = o.w, o.format == JSONFormat
+		n, werr := c.emit(err, entries, lead, trail...)
+		total += n
+		if firstErr == nil {
+			firstErr = werr
+		}
+	}
+	return total, firstErr
+}
@@ -0,0 +1,35 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWithFormatWriter(t *testing.T) {
+	var text, jsonBuf, unused bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &unused,
+	}
+	WithFormatWriter(TextFormat, &text)(logger)
+	WithFormatWriter(JSONFormat, &jsonBuf)(logger)
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: debug message\n[test-123] E: test error\n"
+	if text.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, text.String())
+	}
+	records := decodeJSONLines(t, jsonBuf.String())
+	if len(records) != 2 || records[0]["message"] != "debug message" || records[1]["level"] != "E" {
+		t.Errorf("Expected JSON debug and error records, got %v", records)
+	}
+	if unused.Len() != 0 {
+		t.Errorf("Expected nothing written to the default writer, got %q", unused.String())
+	}
+}
@@ -0,0 +1,53 @@
+package failtrace
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// global is the package-level logger used outside of requests, e.g. during
+// startup. It is unbuffered, so every entry is written immediately, and the
+// mutex makes it safe for concurrent use.
+var global = struct {
+	mu sync.Mutex
+	l  *requestLogger
+}{
+	l: &requestLogger{id: "global", w: os.Stderr, passthrough: true, detached: true},
+}
+
+// SetOutput sets the destination of the package-level logger, which defaults
+// to os.Stderr. It is safe to call while other goroutines log, e.g. to rotate
+// to a new file; entries are written either to the old or the new writer.
+func SetOutput(w io.Writer) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.l.w = w
+}
+
+// Debug writes a debug-level entry through the package-level logger,
+// rendered as "[global] D: message".
+func Debug(msg string) {
+	logGlobal(DebugLevel, msg)
+}
+
+// Info writes an info-level entry through the package-level logger.
+func Info(msg string) {
+	logGlobal(InfoLevel, msg)
+}
+
+// Warn writes a warn-level entry through the package-level logger.
+func Warn(msg string) {
+	logGlobal(WarnLevel, msg)
+}
+
+// Error writes an error-level entry through the package-level logger.
+func Error(msg string) {
+	logGlobal(ErrorLevel, msg)
+}
+
+func logGlobal(level Level, msg string) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.l.log(level, msg)
+}
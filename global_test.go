@@ -0,0 +1,68 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	defer SetOutput(os.Stderr)
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	Info("info message")
+	Error("error message")
+
+	expected := "[global] I: info message\n[global] E: error message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// TestSetOutput_Concurrent is meant to be run with -race.
+func TestSetOutput_Concurrent(t *testing.T) {
+	defer SetOutput(os.Stderr)
+
+	const (
+		goroutines = 20
+		entries    = 200
+	)
+	outputs := make([]*bytes.Buffer, 50)
+	for i := range outputs {
+		outputs[i] = new(bytes.Buffer)
+	}
+	SetOutput(outputs[0])
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < entries; j++ {
+				Info("info message")
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, w := range outputs[1:] {
+			SetOutput(w)
+		}
+	}()
+	wg.Wait()
+	<-done
+
+	total := 0
+	for _, w := range outputs {
+		total += strings.Count(w.String(), "[global] I: info message\n")
+	}
+	if total != goroutines*entries {
+		t.Errorf("Expected %d entries across outputs, got %d", goroutines*entries, total)
+	}
+}
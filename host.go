@@ -0,0 +1,29 @@
+package failtrace
+
+import (
+	"os"
+	"strconv"
+)
+
+// hostname and pid identify the process in flushed lines. They are looked up
+// once at startup.
+var (
+	hostname = lookupHostname()
+	pid      = os.Getpid()
+)
+
+func lookupHostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return h
+}
+
+// WithHostInfo renders the hostname and process ID in the prefix of every
+// flushed line, as "[host:pid:id]".
+func WithHostInfo() Option {
+	return func(l *requestLogger) {
+		l.host = hostname + ":" + strconv.Itoa(pid) + ":"
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWithHostInfo(t *testing.T) {
+	defer func(h string, p int) { hostname, pid = h, p }(hostname, pid)
+	hostname, pid = "web-1", 4242
+
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithHostInfo()(logger)
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[web-1:4242:test-123] D: debug message\n[web-1:4242:test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
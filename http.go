@@ -0,0 +1,51 @@
+package failtrace
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// DebugTraceHeader is the request header enabling the trace trailer of
+// TraceTrailer, and the name of the trailer carrying the trace.
+const DebugTraceHeader = "X-Debug-Trace"
+
+// TraceTrailer is a middleware installing a logger configured by opts for
+// every request. When a request carries "X-Debug-Trace: 1", the trace of the
+// request is returned in the X-Debug-Trace response trailer, one value per
+// line, whether or not it failed, e.g. for debugging with curl --raw. Other
+// requests never receive the trace. Loggers not flushed by the handler are
+// discarded once it returns.
+func TraceTrailer(next http.Handler, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		debug := r.Header.Get(DebugTraceHeader) == "1"
+		opts := opts
+
+		var trace []string
+		if debug {
+			opts = append(opts[:len(opts):len(opts)], OnFlush(func(info FlushInfo) {
+				for _, e := range info.Buffered {
+					trace = append(trace, string(bytes.TrimSuffix(DefaultFormatter(info.ID, e), []byte("\n"))))
+				}
+			}))
+			w.Header().Add("Trailer", DebugTraceHeader)
+		}
+		ctx := WithLogger(r.Context(), opts...)
+
+		// The logger is held out of the pool while the handler runs, so
+		// Flushed still refers to this request once it returns. Loggers
+		// inherited from an outer layer are left to that layer.
+		log := FromContext(ctx)
+		log.held = !log.inherited
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+		if log.held {
+			if !log.Flushed() {
+				log.Discard()
+			}
+			log.release()
+		}
+		for _, line := range trace {
+			w.Header().Add(DebugTraceHeader, line)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTraceTrailer(t *testing.T) {
+	var id string
+	handler := TraceTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := FromContext(r.Context())
+		id = log.ID()
+		log.Debug("handling request")
+		log.Info("done")
+		io.WriteString(w, "ok")
+	}), WithWriter(io.Discard))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DebugTraceHeader, "1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	io.ReadAll(res.Body)
+	expected := []string{"[" + id + "] D: handling request", "[" + id + "] I: done"}
+	got := res.Trailer.Values(DebugTraceHeader)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected trailer %q, got %q", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Line %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	res = rec.Result()
+	io.ReadAll(res.Body)
+	if v := res.Trailer.Values(DebugTraceHeader); len(v) != 0 {
+		t.Errorf("Expected no trailer without the debug header, got %q", v)
+	}
+}
+
+func TestTraceTrailer_FlushedByHandler(t *testing.T) {
+	handler := TraceTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := FromContext(r.Context())
+		log.Debug("handling request")
+		log.FlushIf(errors.New("test error"))
+	}), WithWriter(io.Discard))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DebugTraceHeader, "1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	io.ReadAll(res.Body)
+	if got := res.Trailer.Values(DebugTraceHeader); len(got) != 1 || !strings.HasSuffix(got[0], "D: handling request") {
+		t.Errorf("Expected the trace flushed by the handler, got %q", got)
+	}
+}
+
+func TestTraceTrailer_HeldUntilReturn(t *testing.T) {
+	var log *requestLogger
+	handler := TraceTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log = FromContext(r.Context())
+		log.Debug("handling request")
+		log.FlushIf(errors.New("test error"))
+		if !log.Flushed() || !log.held {
+			t.Error("Expected the flushed logger to be held until the middleware returns")
+		}
+		if len(log.hooks) != 0 {
+			t.Errorf("Expected no flush hook without the debug header, got %d", len(log.hooks))
+		}
+	}), WithWriter(io.Discard))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if log.held {
+		t.Error("Expected the logger to be released once the middleware returns")
+	}
+}
+
+func TestTraceTrailer_Inherited(t *testing.T) {
+	handler := TraceTrailer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling request")
+	}), WithInherit())
+
+	var buf strings.Builder
+	ctx := WithLogger(context.Background(), WithWriter(&buf))
+	outer := FromContext(ctx)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	if outer.Flushed() || outer.held {
+		t.Fatal("Expected the inherited logger to be left to the outer layer")
+	}
+	outer.Flush()
+	if !strings.Contains(buf.String(), "I: handling request") {
+		t.Errorf("Expected the outer flush to carry the entry, got %q", buf.String())
+	}
+}
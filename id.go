@@ -0,0 +1,62 @@
+package failtrace
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDScheme selects how request IDs are generated.
+type IDScheme byte
+
+const (
+	// UUIDv4 generates random UUIDs, e.g. "a76c964f-83a2-4116-ad70-55cfc029d353".
+	UUIDv4 IDScheme = iota
+	// Counter generates short IDs from a random per-process prefix and a
+	// monotonic counter, e.g. "a3f-0001". IDs are unique within a process run
+	// and much cheaper to generate than UUIDs.
+	Counter
+
+	// fixedID always generates "test", see WithDeterministic.
+	fixedID IDScheme = 255
+)
+
+var (
+	idPrefix  = newIDPrefix()
+	idCounter atomic.Uint64
+)
+
+// newIDPrefix returns three random hex digits.
+func newIDPrefix() string {
+	n := rand.Uint32()
+	return string([]byte{hexDigits[n&0xf], hexDigits[n>>4&0xf], hexDigits[n>>8&0xf]})
+}
+
+// WithIDScheme selects how the request ID is generated. Defaults to UUIDv4.
+func WithIDScheme(scheme IDScheme) Option {
+	return func(l *requestLogger) {
+		l.scheme = scheme
+	}
+}
+
+// newID generates a request ID using the scheme.
+func (s IDScheme) newID() string {
+	if s == fixedID {
+		return "test"
+	}
+	if s != Counter {
+		return uuid.New().String()
+	}
+
+	n := strconv.FormatUint(idCounter.Add(1), 10)
+	b := make([]byte, 0, len(idPrefix)+1+max(4, len(n)))
+	b = append(b, idPrefix...)
+	b = append(b, '-')
+	for i := len(n); i < 4; i++ {
+		b = append(b, '0')
+	}
+	b = append(b, n...)
+	return string(b)
+}
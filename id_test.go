@@ -0,0 +1,71 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestIDScheme_Counter(t *testing.T) {
+	const numIDs = 10000
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		seen = make(map[string]bool, numIDs)
+	)
+
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numIDs/10; i++ {
+				id := Counter.newID()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != numIDs {
+		t.Errorf("Expected %d unique IDs, got %d", numIDs, len(seen))
+	}
+	format := regexp.MustCompile(`^[0-9a-f]{3}-\d{4,}$`)
+	for id := range seen {
+		if !format.MatchString(id) {
+			t.Errorf("Unexpected ID format '%s'", id)
+			break
+		}
+	}
+}
+
+func TestWithIDScheme(t *testing.T) {
+	logger := &requestLogger{}
+	WithIDScheme(Counter)(logger)
+
+	if id := logger.ID(); !regexp.MustCompile(`^[0-9a-f]{3}-\d{4,}$`).MatchString(id) {
+		t.Errorf("Expected counter ID, got '%s'", id)
+	}
+
+	logger = &requestLogger{}
+	if id := logger.ID(); len(id) != 36 {
+		t.Errorf("Expected UUID by default, got '%s'", id)
+	}
+}
+
+// BenchmarkIDScheme compares ID generation of the supported schemes
+func BenchmarkIDScheme(b *testing.B) {
+	schemes := map[string]IDScheme{"UUIDv4": UUIDv4, "Counter": Counter}
+
+	for name, scheme := range schemes {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = scheme.newID()
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package failtrace
+
+import "unique"
+
+// DebugStatic logs a debug-level message like Debug, from a message interned
+// once at the call site, typically in a package-level variable. All entries
+// logged with the handle share its storage, which pays off for repeated
+// messages built at runtime, e.g. read from configuration, that would
+// otherwise be retained once per entry. The call costs the same as Debug;
+// string literals already share their storage, so for them Debug is enough.
+//
+// Usage example:
+//
+//	var cacheHit = unique.Make(cfg.CacheHitMessage)
+//
+//	log.DebugStatic(cacheHit)
+func (l *requestLogger) DebugStatic(msg unique.Handle[string]) {
+	if disabled {
+		return
+	}
+	l.log(DebugLevel, msg.Value())
+}
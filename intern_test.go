@@ -0,0 +1,103 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"unique"
+	"unsafe"
+)
+
+func TestRequestLogger_DebugStatic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	cacheHit := unique.Make("cache hit")
+	logger.DebugStatic(cacheHit)
+	logger.Debug("dynamic message")
+	logger.DebugStatic(cacheHit)
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: cache hit\n[test-123] D: dynamic message\n[test-123] D: cache hit\n[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRequestLogger_DebugStatic_With(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+
+	logger.With("k", "v").DebugStatic(unique.Make("static"))
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: static k=v\n[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRequestLogger_DebugStatic_AcrossLoggers(t *testing.T) {
+	var wg sync.WaitGroup
+	loggers := make([]*requestLogger, 10)
+
+	for i := range loggers {
+		loggers[i] = &requestLogger{id: "test", buf: make([]logEntry, 0)}
+		wg.Add(1)
+		go func(l *requestLogger) {
+			defer wg.Done()
+			l.DebugStatic(unique.Make(strings.Clone("shared static message")))
+			l.DebugStatic(unique.Make("another static message"))
+		}(loggers[i])
+	}
+	wg.Wait()
+
+	for i, l := range loggers {
+		if unsafe.StringData(l.buf[0].message) != unsafe.StringData(loggers[0].buf[0].message) {
+			t.Errorf("Logger %d: expected a shared canonical message", i)
+		}
+		if got := l.entry(l.buf[0]).Message; got != "shared static message" {
+			t.Errorf("Logger %d: expected 'shared static message', got '%s'", i, got)
+		}
+		if got := l.entry(l.buf[1]).Message; got != "another static message" {
+			t.Errorf("Logger %d: expected 'another static message', got '%s'", i, got)
+		}
+	}
+}
+
+// staticMessage is interned once, as DebugStatic callers do.
+var staticMessage = unique.Make("static message")
+
+// BenchmarkDebugStatic compares DebugStatic against Debug in a tight loop
+func BenchmarkDebugStatic(b *testing.B) {
+	b.Run("Debug", func(b *testing.B) {
+		logger := &requestLogger{id: "bench-test", buf: make([]logEntry, 0, 32), w: io.Discard}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Debug("static message")
+			if len(logger.buf) == cap(logger.buf) {
+				logger.buf = logger.buf[:0]
+			}
+		}
+	})
+
+	b.Run("DebugStatic", func(b *testing.B) {
+		logger := &requestLogger{id: "bench-test", buf: make([]logEntry, 0, 32), w: io.Discard}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.DebugStatic(staticMessage)
+			if len(logger.buf) == cap(logger.buf) {
+				logger.buf = logger.buf[:0]
+			}
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package failtrace
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// WithJSON renders flushed lines as JSON objects, one per line:
+//
+//	{"id":"...","level":"D","message":"...","name":"auth","user_id":"42"}
+//
+// The name and time keys are omitted when empty, and persistent fields are
+// rendered as top-level keys.
+func WithJSON() Option {
+	return func(l *requestLogger) {
+		l.json = true
+	}
+}
+
+// WithHeaderRecord makes JSON flushes start with a request record carrying the
+// request ID, the time the logger was created and the persistent fields:
+//
+//	{"type":"request","id":"...","ts":"2025-06-12T10:00:00Z","user_id":"42"}
+//
+// Entry records are then tagged with "type":"entry". Enables JSON mode.
+func WithHeaderRecord() Option {
+	return func(l *requestLogger) {
+		l.json = true
+		l.header = true
+	}
+}
+
+// WithJSONArray renders each flush as a single JSON array document holding
+// the entry objects, and the error object last, instead of one object per
+// line. A Flush of an empty buffer writes "[]". Entries written immediately,
+// with WithPassthrough or after an eager error flush, are each written as a
+// one-element array, so every write is a complete JSON document. Enables JSON
+// mode.
+func WithJSONArray() Option {
+	return func(l *requestLogger) {
+		l.json = true
+		l.jsonArray = true
+	}
+}
+
+// beginJSONRecord separates a record from the previous one in a JSON array.
+func (l *requestLogger) beginJSONRecord(b *bytes.Buffer) {
+	if l.jsonArray && !bytes.HasSuffix(b.Bytes(), []byte{'['}) {
+		b.WriteByte(',')
+	}
+}
+
+// endJSONRecord terminates a JSON record; records of an array share a line.
+func (l *requestLogger) endJSONRecord(b *bytes.Buffer) {
+	if !l.jsonArray {
+		b.WriteString(l.lineTerminator())
+	}
+}
+
+// writeHeader renders the request record of a JSON flush into b.
+func (l *requestLogger) writeHeader(b *bytes.Buffer, id string) {
+	l.beginJSONRecord(b)
+	b.WriteString(`{"type":"request","id":`)
+	writeJSONString(b, id)
+	b.WriteString(`,"ts":`)
+	writeJSONString(b, l.start.Format(time.RFC3339Nano))
+	l.writeJSONFields(b)
+	b.WriteByte('}')
+	l.endJSONRecord(b)
+}
+
+// writeJSON renders e as a JSON object line into b.
+func (l *requestLogger) writeJSON(b *bytes.Buffer, id string, e Entry) {
+	l.beginJSONRecord(b)
+	b.WriteByte('{')
+	if l.header {
+		b.WriteString(`"type":"entry",`)
+	}
+	b.WriteString(`"id":`)
+	writeJSONString(b, id)
+	b.WriteString(`,"level":`)
+	writeJSONString(b, string(rune(e.Level)))
+	b.WriteString(`,"message":`)
+	writeJSONString(b, e.Message)
+	if e.Name != "" {
+		b.WriteString(`,"name":`)
+		writeJSONString(b, e.Name)
+	}
+	if !e.Time.IsZero() {
+		b.WriteString(`,"time":`)
+		writeJSONString(b, e.Time.Format(time.RFC3339Nano))
+	}
+	if e.Seq > 0 {
+		b.WriteString(`,"seq":`)
+		b.WriteString(strconv.FormatUint(uint64(e.Seq), 10))
+	}
+	l.writeJSONFields(b)
+	b.WriteByte('}')
+	l.endJSONRecord(b)
+}
+
+// writeJSONFields renders the persistent fields as additional JSON keys.
+func (l *requestLogger) writeJSONFields(b *bytes.Buffer) {
+	for _, f := range l.fields {
+		b.WriteByte(',')
+		writeJSONString(b, f.key)
+		b.WriteByte(':')
+		writeJSONString(b, f.value)
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString renders s as a quoted JSON string into b.
+func writeJSONString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c == '\n':
+				b.WriteString(`\n`)
+			case c == '\r':
+				b.WriteString(`\r`)
+			case c == '\t':
+				b.WriteString(`\t`)
+			case c < 0x20:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[c>>4])
+				b.WriteByte(hexDigits[c&0xf])
+			default:
+				b.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(`\ufffd`)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	b.WriteByte('"')
+}
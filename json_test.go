@@ -0,0 +1,224 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeJSONLines(t *testing.T, s string) []map[string]string {
+	t.Helper()
+
+	var records []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		var record map[string]string
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithJSON()(logger)
+
+	logger.Named("auth").Debug("quote \" and newline \n")
+	logger.FlushIf(errors.New("test error"))
+
+	records := decodeJSONLines(t, buf.String())
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if r := records[0]; r["id"] != "test-123" || r["level"] != "D" || r["message"] != "quote \" and newline \n" || r["name"] != "auth" {
+		t.Errorf("Unexpected entry record: %v", r)
+	}
+	if r := records[1]; r["level"] != "E" || r["message"] != "test error" {
+		t.Errorf("Unexpected error record: %v", r)
+	}
+	if _, ok := records[0]["type"]; ok {
+		t.Error("Expected no type key without header record")
+	}
+}
+
+func TestWithHeaderRecord(t *testing.T) {
+	start := time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	logger := New(&buf, WithHeaderRecord())
+	logger.id = "test-123"
+	logger.fields = append(logger.fields, field{key: "tenant", value: "acme"})
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	records := decodeJSONLines(t, buf.String())
+	expected := []map[string]string{
+		{"type": "request", "id": "test-123", "ts": "2025-06-12T10:00:00Z", "tenant": "acme"},
+		{"type": "entry", "id": "test-123", "level": "I", "message": "info message", "tenant": "acme"},
+		{"type": "entry", "id": "test-123", "level": "E", "message": "test error", "tenant": "acme"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for i, e := range expected {
+		for k, v := range e {
+			if records[i][k] != v {
+				t.Errorf("Record %d: expected %s=%q, got %q", i, k, v, records[i][k])
+			}
+		}
+	}
+}
+
+func TestWithHeaderRecord_NoEntries(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithHeaderRecord())
+
+	logger.FlushIf(errors.New("test error"))
+
+	records := decodeJSONLines(t, buf.String())
+	if len(records) != 2 || records[0]["type"] != "request" || records[1]["message"] != "test error" {
+		t.Errorf("Expected header and error record, got %v", records)
+	}
+}
+
+func TestWithJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithJSONArray()(logger)
+
+	logger.Debug("debug message")
+	logger.Named("auth").Warn(`say "hi"`)
+	logger.FlushIf(errors.New("test error"))
+
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Expected a single line, got %q", buf.String())
+	}
+	var entries []Entry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("Invalid JSON array %q: %v", buf.String(), err)
+	}
+	expected := []Entry{
+		{Level: DebugLevel, Message: "debug message"},
+		{Level: WarnLevel, Message: `say "hi"`, Name: "auth"},
+		{Level: ErrorLevel, Message: "test error"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		if entries[i] != e {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, entries[i])
+		}
+	}
+}
+
+func TestWithJSONArray_Correlation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithJSONArray(), WithCorrelationToken(""))
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	var records []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Invalid JSON array %q: %v", buf.String(), err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Expected 2 records, got %d", len(records))
+	}
+}
+
+func TestWithJSONArray_Attachments(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithJSONArray())
+	logger.id = "test-123"
+
+	logger.Debug("debug message")
+	logger.Attach("body", []byte(`{"qty":-1}`))
+	logger.Attach("blob", []byte{0xff, 0x00})
+	logger.FlushIf(errors.New("test error"))
+
+	var records []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Invalid JSON array %q: %v", buf.String(), err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %d", len(records))
+	}
+	body, blob := records[2], records[3]
+	if body["type"] != "attachment" || body["name"] != "body" || body["data"] != `{"qty":-1}` || body["bytes"] != 10.0 {
+		t.Errorf("Unexpected attachment record %v", body)
+	}
+	if blob["name"] != "blob" || blob["data_base64"] != "/wA=" {
+		t.Errorf("Unexpected binary attachment record %v", blob)
+	}
+}
+
+func TestWithJSONArray_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithJSONArray())
+
+	logger.FlushIf(nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written for a discarded buffer, got %q", buf.String())
+	}
+
+	logger.Flush()
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected %q, got %q", "[]\n", buf.String())
+	}
+}
+
+func TestWithJSONArray_Immediate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opt      Option
+		expected []string
+	}{
+		{"passthrough", WithPassthrough(), []string{"debug message", "error message", "info message"}},
+		{"eager", WithEagerErrorFlush(), []string{"debug message", "error message", "info message", "test error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, WithJSONArray(), tt.opt)
+			logger.Debug("debug message")
+			logger.Error("error message")
+			logger.Info("info message")
+			logger.FlushIf(errors.New("test error"))
+
+			dec := json.NewDecoder(&buf)
+			var messages []string
+			for dec.More() {
+				var entries []Entry
+				if err := dec.Decode(&entries); err != nil {
+					t.Fatalf("Invalid JSON array in %q: %v", buf.String(), err)
+				}
+				for _, e := range entries {
+					messages = append(messages, e.Message)
+				}
+			}
+			if strings.Join(messages, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %q, got %q", tt.expected, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package failtrace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+// leakOutput receives the warnings of WithLeakDetection; replaced in tests.
+var leakOutput io.Writer = os.Stderr
+
+// WithLeakDetection warns on stderr when the logger is garbage collected
+// without having been flushed, typically because a deferred FlushIf is
+// missing, so traces are not dropped silently. The check is cleared when the
+// logger returns to the pool, so pooling is unaffected. It only applies to
+// pooled loggers installed by WithLogger.
+func WithLeakDetection() Option {
+	return func(l *requestLogger) {
+		if l.detached || l.root != nil || l.leakCheck {
+			return
+		}
+		l.leakCheck = true
+		runtime.SetFinalizer(l, leaked)
+	}
+}
+
+// leaked reports a logger collected without being flushed.
+func leaked(l *requestLogger) {
+	id := l.id
+	if id == "" {
+		id = "(no id)"
+	}
+	fmt.Fprintf(leakOutput, "failtrace: logger %s garbage collected without flush, %d entries lost\n", id, len(l.buf))
+}
+
+// clearLeakCheck removes the finalizer set by WithLeakDetection.
+func (l *requestLogger) clearLeakCheck() {
+	if l.leakCheck {
+		l.leakCheck = false
+		runtime.SetFinalizer(l, nil)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a strings.Builder safe for use from the finalizer goroutine.
+type syncBuffer struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
+
+func TestWithLeakDetection(t *testing.T) {
+	out := &syncBuffer{}
+	leakOutput = out
+	defer func() { leakOutput = os.Stderr }()
+
+	// A flushed logger goes back to the pool without a warning.
+	flushed := FromContext(WithLogger(context.Background(), WithLeakDetection()))
+	flushed.Debug("debug message")
+	flushed.FlushIf(nil)
+	if flushed.leakCheck {
+		t.Error("Expected the leak check to be cleared on flush")
+	}
+
+	func() {
+		logger := FromContext(WithLogger(context.Background(), WithLeakDetection()))
+		logger.id = "leaky-1"
+		logger.Debug("debug message")
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "leaky-1") && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expected := "failtrace: logger leaky-1 garbage collected without flush, 1 entries lost\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
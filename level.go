@@ -0,0 +1,134 @@
+package failtrace
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// levelTable holds the name and rank of every known level, indexed by level.
+type levelTable struct {
+	known [256]bool
+	names [256]string
+	ranks [256]int
+}
+
+var (
+	levels   atomic.Pointer[levelTable]
+	levelsMu sync.Mutex
+)
+
+func init() {
+	t := &levelTable{}
+	for _, l := range []struct {
+		level Level
+		name  string
+		rank  int
+	}{
+		{DebugLevel, "debug", 10},
+		{InfoLevel, "info", 20},
+		{WarnLevel, "warn", 30},
+		{ErrorLevel, "error", 40},
+		{FatalLevel, "fatal", 50},
+	} {
+		t.known[l.level] = true
+		t.names[l.level] = l.name
+		t.ranks[l.level] = l.rank
+	}
+	levels.Store(t)
+}
+
+// RegisterLevel registers an application-specific level, so that it has a
+// name and takes part in severity comparisons such as WithMinLevel and
+// FlushIfLevel. The built-in levels rank debug 10, info 20, warn 30, error 40
+// and fatal 50. Registration is process-wide; registering a level or name
+// that is already known returns an error.
+//
+// Usage example:
+//
+//	const SecurityLevel failtrace.Level = 'S'
+//
+//	func init() {
+//		if err := failtrace.RegisterLevel(SecurityLevel, "security", 35); err != nil {
+//			panic(err)
+//		}
+//	}
+func RegisterLevel(level Level, name string, rank int) error {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	cur := levels.Load()
+	if cur.known[level] {
+		return fmt.Errorf("failtrace: level %q already registered as %q", rune(level), cur.names[level])
+	}
+	for i, n := range cur.names {
+		if cur.known[i] && n == name {
+			return fmt.Errorf("failtrace: level name %q already registered for %q", name, rune(i))
+		}
+	}
+
+	t := *cur
+	t.known[level] = true
+	t.names[level] = name
+	t.ranks[level] = rank
+	levels.Store(&t)
+	return nil
+}
+
+// String returns the registered name of the level, or the level character
+// itself if it is unknown.
+func (l Level) String() string {
+	if t := levels.Load(); t.known[l] {
+		return t.names[l]
+	}
+	return string(rune(l))
+}
+
+// MarshalText encodes the level as its character, as in the JSON format.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte{byte(l)}, nil
+}
+
+// UnmarshalText decodes a level from its character, so JSON output can be
+// decoded back into entries.
+func (l *Level) UnmarshalText(text []byte) error {
+	if len(text) != 1 {
+		return fmt.Errorf("failtrace: invalid level %q", text)
+	}
+	*l = Level(text[0])
+	return nil
+}
+
+// rank returns the severity of the level. Unknown levels rank like errors,
+// so they are never filtered out.
+func (l Level) rank() int {
+	t := levels.Load()
+	if t.known[l] {
+		return t.ranks[l]
+	}
+	return t.ranks[ErrorLevel]
+}
+
+// WithMinLevel drops entries ranking below level when they are logged.
+func WithMinLevel(level Level) Option {
+	return func(l *requestLogger) {
+		l.minLevel = level
+	}
+}
+
+// FlushIfLevel writes the buffered entries if at least one of them ranks at
+// or above level and discards them otherwise, then returns the logger to the
+// pool.
+func (l *requestLogger) FlushIfLevel(level Level) {
+	if l.inherited {
+		return
+	}
+	o := l.owner()
+	for _, e := range o.buf {
+		if e.level.rank() >= level.rank() {
+			o.Flush()
+			return
+		}
+	}
+	o.Discard()
+}
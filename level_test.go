@@ -0,0 +1,81 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const securityLevel Level = 'S'
+
+func init() {
+	if err := RegisterLevel(securityLevel, "security", 35); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterLevel_Collisions(t *testing.T) {
+	if err := RegisterLevel(ErrorLevel, "fatal", 50); err == nil {
+		t.Error("Expected error registering a built-in level")
+	}
+	if err := RegisterLevel('X', "security", 50); err == nil {
+		t.Error("Expected error registering a duplicate name")
+	}
+}
+
+func TestLevel_String(t *testing.T) {
+	tests := map[Level]string{
+		DebugLevel:    "debug",
+		ErrorLevel:    "error",
+		securityLevel: "security",
+		Level('?'):    "?",
+	}
+	for level, expected := range tests {
+		if got := level.String(); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestWithMinLevel(t *testing.T) {
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0)}
+	WithMinLevel(WarnLevel)(logger)
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Warn("warn message")
+	logger.log(securityLevel, "security message")
+	logger.Error("error message")
+
+	if len(logger.buf) != 3 {
+		t.Errorf("Expected 3 entries at or above warn, got %d", len(logger.buf))
+	}
+}
+
+func TestFlushIfLevel_CustomLevel(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger := func() *requestLogger {
+		return &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	}
+
+	logger := newLogger()
+	logger.Debug("debug message")
+	logger.log(securityLevel, "token reuse detected")
+	logger.FlushIfLevel(WarnLevel)
+
+	expected := "[test-123] D: debug message\n[test-123] S: token reuse detected\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	logger = newLogger()
+	logger.log(securityLevel, "token reuse detected")
+	logger.FlushIfLevel(ErrorLevel)
+
+	if strings.TrimSpace(buf.String()) != "" {
+		t.Errorf("Expected security entry to rank below error, got %q", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package failtrace
+
+// WithMaxBytes bounds the total length of the buffered messages to n bytes.
+// When an entry would exceed it, the oldest entries are dropped until the
+// buffer fits again, and an error flush starts with a line telling how many
+// bytes were dropped. The latest entry is always kept, so one larger than n
+// on its own stays alone in the buffer; WithMaxMessageBytes bounds single
+// messages. Unlike a cap on the entry count, this bounds memory when message
+// sizes vary widely.
+func WithMaxBytes(n int) Option {
+	return func(l *requestLogger) {
+		l.maxBytes = n
+	}
+}
+
+// push appends e to the buffer. Every buffered entry goes through it, so it
+// keeps the running total of WithMaxBytes and evicts as needed.
+func (l *requestLogger) push(e logEntry) {
+	l.buf = append(l.buf, e)
+	if l.maxBytes > 0 {
+		l.bufBytes += len(e.message)
+		l.evict()
+	}
+}
+
+// evict drops the oldest entries until the buffered messages fit in maxBytes,
+// or only the latest entry is left.
+// The buffer is resliced past them instead of shifted, so eviction costs only
+// the dropped entries; append reclaims the space when it grows the buffer.
+func (l *requestLogger) evict() {
+	n := 0
+	for l.bufBytes > l.maxBytes && n < len(l.buf)-1 {
+		size := len(l.buf[n].message)
+		l.bufBytes -= size
+		l.droppedBytes += size
+		n++
+	}
+	clear(l.buf[:n])
+	l.buf = l.buf[n:]
+	if len(l.meta) > 2*max(len(l.buf), 16) {
+		l.compactMeta()
+	}
+}
+
+// compactMeta drops the side slice records of evicted entries, renumbering
+// the live ones. It runs once the side slice has doubled past the live
+// entries, so its cost is amortized over the evictions.
+func (l *requestLogger) compactMeta() {
+	meta := l.meta[:0:0]
+	for i, e := range l.buf {
+		if e.meta != 0 {
+			meta = append(meta, l.meta[e.meta-1])
+			l.buf[i].meta = uint32(len(meta))
+		}
+	}
+	l.meta = meta
+}
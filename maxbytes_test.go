@@ -0,0 +1,78 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxBytes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithMaxBytes(10))
+	logger.id = "test-123"
+	logger.Debug("aaaa") // 4 bytes
+	logger.Debug("bbbb") // 8 bytes
+	logger.Debug("cc")   // 10 bytes
+	logger.Debug("ddd")  // 13 bytes, drops "aaaa"
+
+	if logger.bufBytes > 10 {
+		t.Errorf("Expected at most 10 buffered bytes, got %d", logger.bufBytes)
+	}
+	if len(logger.buf) != 3 {
+		t.Errorf("Expected 3 buffered entries, got %d", len(logger.buf))
+	}
+
+	logger.Debug(strings.Repeat("e", 9)) // drops "bbbb", "cc" and "ddd"
+	if logger.bufBytes != 9 || len(logger.buf) != 1 {
+		t.Errorf("Expected 1 entry of 9 bytes, got %d entries of %d bytes", len(logger.buf), logger.bufBytes)
+	}
+
+	logger.FlushIf(errors.New("test error"))
+	expected := "[test-123] W: ... (13 bytes of earlier entries dropped)\n" +
+		"[test-123] D: eeeeeeeee\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if logger.bufBytes != 0 || logger.droppedBytes != 0 {
+		t.Errorf("Expected the byte counts to be cleared, got %d and %d", logger.bufBytes, logger.droppedBytes)
+	}
+}
+
+func TestWithMaxBytesOversizedEntry(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithMaxBytes(4))
+	logger.id = "test-123"
+	logger.Debug("ab")
+	logger.Debug("too long")
+
+	if len(logger.buf) != 1 || logger.bufBytes != 8 {
+		t.Errorf("Expected the oversized entry alone, got %d entries of %d bytes", len(logger.buf), logger.bufBytes)
+	}
+
+	logger.FlushIf(errors.New("test error"))
+	expected := "[test-123] W: ... (2 bytes of earlier entries dropped)\n" +
+		"[test-123] D: too long\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithMaxBytesGrowthWarn(t *testing.T) {
+	logger := New(nil, WithMaxBytes(10), WithGrowthWarn(1))
+	for i := 0; i < 20; i++ {
+		logger.Debug("abcde")
+	}
+
+	total := 0
+	for _, e := range logger.buf {
+		total += len(e.message)
+	}
+	if total != logger.bufBytes || total > 10 {
+		t.Errorf("Expected at most 10 buffered bytes counted as %d, got %d", logger.bufBytes, total)
+	}
+}
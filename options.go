@@ -0,0 +1,255 @@
+package failtrace
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Option configures a request logger. Options are applied by WithLogger after
+// the logger has been taken from the pool and reset.
+type Option func(*requestLogger)
+
+// WithWriter sets the destination of flushed output. Defaults to os.Stderr.
+func WithWriter(w io.Writer) Option {
+	return func(l *requestLogger) {
+		l.w = w
+	}
+}
+
+// WithName tags every entry of the logger with the given component name,
+// rendered as "[id][name] L: message" on flush.
+func WithName(name string) Option {
+	return func(l *requestLogger) {
+		l.name = name
+	}
+}
+
+// WithWorkerLabel adds a "[worker=label]" segment after the request ID on
+// every flushed line, e.g. to tell apart the workers of a pool.
+func WithWorkerLabel(label string) Option {
+	return func(l *requestLogger) {
+		l.worker = label
+	}
+}
+
+// WithPrefix prepends s and a space to every flushed line, before the request
+// ID, e.g. "svc=checkout" to tell apart services sharing one file.
+func WithPrefix(s string) Option {
+	return func(l *requestLogger) {
+		l.prefix = s + " "
+	}
+}
+
+// WithLineTerminator sets the terminator appended to every flushed line,
+// including the error line. Defaults to "\n"; use "\r\n" for CRLF output.
+func WithLineTerminator(s string) Option {
+	return func(l *requestLogger) {
+		l.eol = s
+	}
+}
+
+// WithFormatter replaces the built-in line format with fn, which is called for
+// every buffered entry on flush. The trailing error of FlushIf is passed to fn
+// as an ErrorLevel entry. See DefaultFormatter for the built-in format.
+func WithFormatter(fn func(id string, e Entry) []byte) Option {
+	return func(l *requestLogger) {
+		l.format = fn
+	}
+}
+
+// FlushInfo describes a flush to the OnFlush hooks.
+type FlushInfo struct {
+	// ID is the request ID of the logger.
+	ID string
+	// Entries is the number of buffered entries.
+	Entries int
+	// Err is the flush error, nil for Flush and successful FlushIf calls.
+	Err error
+	// Duration is the time elapsed from the creation of the logger, or its
+	// reuse from the pool, to the flush.
+	Duration time.Duration
+	// Buffered holds the buffered entries. It is a copy and may be retained.
+	Buffered []Entry
+}
+
+// OnFlush registers fn to be called whenever the logger is flushed, before it
+// is returned to the pool.
+func OnFlush(fn func(info FlushInfo)) Option {
+	return func(l *requestLogger) {
+		l.hooks = append(l.hooks, fn)
+	}
+}
+
+// WithSeparator appends sep verbatim after the last line of every flush that
+// writes output, e.g. "\n" for a blank line or "---\n".
+func WithSeparator(sep string) Option {
+	return func(l *requestLogger) {
+		l.sep = sep
+	}
+}
+
+// WithFlushPredicate makes FlushIf write only errors for which fn returns
+// true; other errors are treated like a nil error and the buffer is discarded.
+// By default any non-nil error is flushed.
+//
+//	failtrace.WithFlushPredicate(func(err error) bool {
+//		return !errors.Is(err, context.Canceled)
+//	})
+func WithFlushPredicate(fn func(err error) bool) Option {
+	return func(l *requestLogger) {
+		l.shouldFlush = fn
+	}
+}
+
+// WithTailOnly limits FlushIf to the last n buffered entries before the error
+// line. Omitted entries are summarized by a leading "... (M earlier entries
+// omitted)" line. Flush is unaffected; n <= 0 writes all entries.
+func WithTailOnly(n int) Option {
+	return func(l *requestLogger) {
+		l.tail = n
+	}
+}
+
+// WithIndentMultiline renders an error whose message spans several lines as
+// one error line per message line, each with the full "[id] E: " prefix.
+// By default the message is written as is on a single line.
+func WithIndentMultiline() Option {
+	return func(l *requestLogger) {
+		l.indentMultiline = true
+	}
+}
+
+// WithMaxMessageBytes truncates messages longer than n bytes when they are
+// logged, marking them with a " …(truncated)" suffix. Formatted messages are
+// truncated after formatting. n <= 0 disables truncation.
+func WithMaxMessageBytes(n int) Option {
+	return func(l *requestLogger) {
+		l.maxMsg = n
+	}
+}
+
+// WithGrowthWarn appends a single "log buffer exceeded N entries" warning the
+// first time the buffer grows past threshold entries, as an early sign of a
+// runaway request.
+func WithGrowthWarn(threshold int) Option {
+	return func(l *requestLogger) {
+		l.growthWarn = threshold
+	}
+}
+
+// WithEagerErrorFlush writes the buffer as soon as an entry at ErrorLevel or
+// above is logged, e.g. by Error or Errorf, instead of waiting for FlushIf, so
+// the trace is captured even if the handler later panics without a deferred
+// flush. From then on entries are written immediately, as with
+// WithPassthrough, and FlushIf still writes the error line of a failed
+// request before returning the logger to the pool.
+func WithEagerErrorFlush() Option {
+	return func(l *requestLogger) {
+		l.eagerError = true
+	}
+}
+
+// WithPassthrough disables buffering: every entry is written to the writer as
+// soon as it is logged, in the same format as a flush would render it. FlushIf
+// and Flush then only return the logger to the pool. It is mainly meant for
+// local development.
+func WithPassthrough() Option {
+	return func(l *requestLogger) {
+		l.passthrough = true
+	}
+}
+
+// WithRootCauseOnly renders only the root cause of the flushed error on the
+// error line, found by unwrapping it with errors.Unwrap until it wraps no
+// other error. By default the full message of the error chain is rendered.
+func WithRootCauseOnly() Option {
+	return func(l *requestLogger) {
+		l.rootCause = true
+	}
+}
+
+// rootCause returns the innermost error wrapped by err.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// WithoutErrorLine makes FlushIf write only the buffered entries when err is
+// not nil, without the synthesized "[id] E: err" line, for callers that log
+// the error themselves.
+func WithoutErrorLine() Option {
+	return func(l *requestLogger) {
+		l.noErrorLine = true
+	}
+}
+
+// WithInherit makes WithLogger reuse the logger already stored in the context,
+// if any, instead of shadowing it with a new one. The returned context then
+// carries a child scope, like Named, writing into the existing buffer under
+// the existing ID; WithName names the child and other options are ignored.
+// The flush methods of the child and of its scopes are no-ops, so the usual
+// deferred FlushIf of an inner layer leaves the trace to the layer that
+// installed the logger, which alone flushes it and returns it to the pool.
+//
+//	ctx = failtrace.WithLogger(ctx, failtrace.WithInherit(), failtrace.WithName("repo"))
+func WithInherit() Option {
+	return func(l *requestLogger) {
+		l.inherit = true
+	}
+}
+
+// WithCorrelationToken writes a leading line once per flush, before the
+// entries, so log aggregators can group the lines of a trace. format is a fmt
+// format receiving the request ID and the logger's start time in RFC 3339;
+// an empty format uses "trace_id=%s request_start=%s". The line is left out
+// in JSON mode, whose records carry the ID already; see WithHeaderRecord for
+// the start time.
+func WithCorrelationToken(format string) Option {
+	if format == "" {
+		format = "trace_id=%s request_start=%s"
+	}
+	return func(l *requestLogger) {
+		l.correlation = format
+	}
+}
+
+// WithSkipDebugOnlyFlush makes FlushIf drop the buffered entries and write only
+// the error line when every buffered entry is at DebugLevel. If any entry has
+// another level, the whole buffer is written as usual.
+func WithSkipDebugOnlyFlush() Option {
+	return func(l *requestLogger) {
+		l.skipDebugOnly = true
+	}
+}
+
+// WithDeterministic makes the output of a logger byte-identical across runs,
+// for golden-file tests: the request ID is always "test", and timestamps, step
+// timings, host info, correlation and deadline lines are turned off. Options
+// given after it may turn some of them back on.
+func WithDeterministic() Option {
+	return func(l *requestLogger) {
+		l.scheme = fixedID
+		l.stamp = false
+		l.timeLayout = ""
+		l.timings = false
+		l.host = ""
+		l.correlation = ""
+		l.deterministic = true
+	}
+}
+
+// debugOnly reports whether all entries are at DebugLevel.
+func debugOnly(entries []logEntry) bool {
+	for _, e := range entries {
+		if e.level != DebugLevel {
+			return false
+		}
+	}
+	return true
+}
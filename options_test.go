@@ -0,0 +1,706 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithName(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), WithName("auth"))
+	logger := FromContext(ctx)
+	logger.w = &buf
+	id := logger.ID()
+
+	logger.Debug("checking credentials")
+	logger.FlushIf(errors.New("denied"))
+
+	expected := "[" + id + "][auth] D: checking credentials\n" +
+		"[" + id + "][auth] E: denied\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	auth := logger.Named("auth")
+	logger.Info("request start")
+	auth.Debug("checking credentials")
+	auth.Named("token").Warn("token expires soon")
+
+	logger.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expectedLines := []string{
+		"[test-123] I: request start",
+		"[test-123][auth] D: checking credentials",
+		"[test-123][auth.token] W: token expires soon",
+	}
+
+	if len(lines) != len(expectedLines) {
+		t.Fatalf("Expected %d lines of output, got %d", len(expectedLines), len(lines))
+	}
+	for i, expected := range expectedLines {
+		if lines[i] != expected {
+			t.Errorf("Line %d: expected '%s', got '%s'", i, expected, lines[i])
+		}
+	}
+}
+
+func TestWithLineTerminator(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithLineTerminator("\r\n")(logger)
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] I: info message\r\n[test-123] E: test error\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithLineTerminator_Default(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] I: info message\n[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithFormatter(func(id string, e Entry) []byte {
+		return []byte(fmt.Sprintf("%s,%c,%q\n", id, e.Level, e.Message))
+	})(logger)
+
+	logger.Debug("debug message")
+	logger.Warn("warn, with comma")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "test-123,D,\"debug message\"\n" +
+		"test-123,W,\"warn, with comma\"\n" +
+		"test-123,E,\"test error\"\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestDefaultFormatter(t *testing.T) {
+	got := string(DefaultFormatter("test-123", Entry{Level: InfoLevel, Message: "info message", Name: "auth"}))
+
+	if got != "[test-123][auth] I: info message\n" {
+		t.Errorf("Expected default format, got %q", got)
+	}
+}
+
+func TestOnFlush(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	var got FlushInfo
+	logger := &requestLogger{
+		id:    "test-123",
+		start: now(),
+		buf:   make([]logEntry, 0),
+		w:     io.Discard,
+	}
+	OnFlush(func(info FlushInfo) {
+		got = info
+	})(logger)
+
+	testErr := errors.New("test error")
+	logger.Debug("debug message")
+	now = func() time.Time { return start.Add(250 * time.Millisecond) }
+	logger.FlushIf(testErr)
+
+	if got.ID != "test-123" {
+		t.Errorf("Expected 'test-123', got '%s'", got.ID)
+	}
+	if got.Entries != 1 || len(got.Buffered) != 1 || got.Buffered[0] != (Entry{Level: DebugLevel, Message: "debug message"}) {
+		t.Errorf("Expected the buffered debug entry, got %d entries %v", got.Entries, got.Buffered)
+	}
+	if got.Err != testErr {
+		t.Errorf("Expected %v, got %v", testErr, got.Err)
+	}
+	if got.Duration != 250*time.Millisecond {
+		t.Errorf("Expected duration 250ms, got %v", got.Duration)
+	}
+}
+
+func TestWithSeparator(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger := func() *requestLogger {
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+		WithSeparator("---\n")(logger)
+		return logger
+	}
+
+	logger := newLogger()
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: debug message\n[test-123] E: test error\n---\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	logger = newLogger()
+	logger.Debug("debug message")
+	logger.FlushIf(nil)
+
+	if buf.String() != "" {
+		t.Errorf("Expected no separator without output, got %q", buf.String())
+	}
+}
+
+func TestWithFlushPredicate(t *testing.T) {
+	var buf bytes.Buffer
+	flush := func(err error) {
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+		WithFlushPredicate(func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		})(logger)
+		logger.Debug("debug message")
+		logger.FlushIf(err)
+	}
+
+	flush(fmt.Errorf("request aborted: %w", context.Canceled))
+	if buf.String() != "" {
+		t.Errorf("Expected no output for ignored error, got %q", buf.String())
+	}
+
+	flush(errors.New("test error"))
+	expected := "[test-123] D: debug message\n[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithWorkerLabel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithWorkerLabel("3")(logger)
+
+	logger.Named("auth").Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123][worker=3][auth] D: debug message\n[test-123][worker=3] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	logger = &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	logger.Debug("debug message")
+	logger.Flush()
+
+	if strings.Contains(buf.String(), "worker=") {
+		t.Errorf("Expected no worker label when unset, got %q", buf.String())
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), WithWriter(&buf), WithPrefix("svc=checkout"))
+	logger := FromContext(ctx)
+	id := logger.ID()
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "svc=checkout [" + id + "] D: debug message\nsvc=checkout [" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// A pooled logger reused without the option has no prefix.
+	buf.Reset()
+	logger = FromContext(WithLogger(context.Background(), WithWriter(&buf)))
+	logger.Debug("debug message")
+	logger.Flush()
+	if strings.HasPrefix(buf.String(), "svc=") {
+		t.Errorf("Expected no prefix when unset, got %q", buf.String())
+	}
+}
+
+func TestWithTailOnly(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithTailOnly(3)(logger)
+
+	for i := 0; i < 10; i++ {
+		logger.Debugf("step %d", i)
+	}
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] I: ... (7 earlier entries omitted)\n" +
+		"[test-123] D: step 7\n" +
+		"[test-123] D: step 8\n" +
+		"[test-123] D: step 9\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithTailOnly_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithTailOnly(3)(logger)
+
+	for i := 0; i < 10; i++ {
+		logger.Debugf("step %d", i)
+	}
+	logger.Flush()
+
+	if n := strings.Count(buf.String(), "\n"); n != 10 {
+		t.Errorf("Expected Flush to write all 10 entries, got %d lines", n)
+	}
+}
+
+func TestWithIndentMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithIndentMultiline()(logger)
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("validation failed:\n{\n  \"field\": \"email\"\n}"))
+
+	expected := "[test-123] D: debug message\n" +
+		"[test-123] E: validation failed:\n" +
+		"[test-123] E: {\n" +
+		"[test-123] E:   \"field\": \"email\"\n" +
+		"[test-123] E: }\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithMaxMessageBytes(t *testing.T) {
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   io.Discard,
+	}
+	WithMaxMessageBytes(10)(logger)
+
+	logger.Debug(strings.Repeat("a", 1<<20))
+	logger.Infof("%s", strings.Repeat("b", 100))
+	logger.Warn("short")
+	logger.Error("aéééééé") // 13 bytes, byte 10 is inside a rune
+
+	expected := []string{
+		strings.Repeat("a", 10) + " …(truncated)",
+		strings.Repeat("b", 10) + " …(truncated)",
+		"short",
+		"aéééé …(truncated)",
+	}
+	for i, msg := range expected {
+		if logger.buf[i].message != msg {
+			t.Errorf("Entry %d: expected %q, got %q", i, msg, logger.buf[i].message)
+		}
+	}
+}
+
+func TestWithGrowthWarn(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithGrowthWarn(3)(logger)
+
+	for i := 0; i < 10; i++ {
+		logger.Debugf("step %d", i)
+	}
+	logger.FlushIf(errors.New("test error"))
+
+	if n := strings.Count(buf.String(), "W: log buffer exceeded 3 entries\n"); n != 1 {
+		t.Errorf("Expected exactly one growth warning, got %d", n)
+	}
+	if !strings.Contains(buf.String(), "D: step 3\n[test-123] W: log buffer exceeded 3 entries\n") {
+		t.Errorf("Expected warning right after crossing the threshold, got %q", buf.String())
+	}
+}
+
+func TestWithPassthrough(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithPassthrough()(logger)
+
+	logger.Debug("debug message")
+	if expected := "[test-123] D: debug message\n"; buf.String() != expected {
+		t.Errorf("Expected %q before flush, got %q", expected, buf.String())
+	}
+	logger.Info("info message")
+	if len(logger.buf) != 0 {
+		t.Errorf("Expected nothing buffered, got %d entries", len(logger.buf))
+	}
+
+	logger.FlushIf(errors.New("test error"))
+	expected := "[test-123] D: debug message\n[test-123] I: info message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithoutErrorLine(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithoutErrorLine()(logger)
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: debug message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithInherit(t *testing.T) {
+	var buf bytes.Buffer
+	outer := WithLogger(context.Background(), WithWriter(&buf))
+	inner := WithLogger(outer, WithInherit(), WithName("repo"))
+	logger := FromContext(outer)
+	id := logger.ID()
+
+	logger.Debug("outer message")
+	FromContext(inner).Info("inner message")
+	if got, _ := IDFromContext(inner); got != id {
+		t.Errorf("Expected inner ID %q, got %q", id, got)
+	}
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "] D: outer message\n" +
+		"[" + id + "][repo] I: inner message\n" +
+		"[" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Without a logger in the context, WithInherit installs a new one.
+	if !HasLogger(WithLogger(context.Background(), WithInherit())) {
+		t.Error("Expected a new logger when there is none to inherit")
+	}
+}
+
+func TestWithInherit_InnerFlush(t *testing.T) {
+	var buf bytes.Buffer
+	outer := WithLogger(context.Background(), WithWriter(&buf))
+	logger := FromContext(outer)
+	id := logger.ID()
+	logger.Debug("outer message")
+
+	func() {
+		inner := WithLogger(outer, WithInherit(), WithName("repo"))
+		defer FromContext(inner).FlushIf(nil)
+		FromContext(inner).Info("inner message")
+		FromContext(inner).Named("db").FlushIf(errors.New("inner error"))
+	}()
+
+	if logger.Flushed() {
+		t.Fatal("Expected the inner flush to leave the outer logger out of the pool")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output from the inner layer, got %q", buf.String())
+	}
+	logger.Debug("after inner")
+	logger.FlushIf(errors.New("boom"))
+
+	expected := "[" + id + "] D: outer message\n" +
+		"[" + id + "][repo] I: inner message\n" +
+		"[" + id + "] D: after inner\n" +
+		"[" + id + "] E: boom\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithInherit_InnerFlushIfLevel(t *testing.T) {
+	var buf bytes.Buffer
+	outer := WithLogger(context.Background(), WithWriter(&buf))
+	logger := FromContext(outer)
+	id := logger.ID()
+	logger.Debug("outer message")
+
+	inner := FromContext(WithLogger(outer, WithInherit()))
+	inner.Warn("inner warning")
+	inner.FlushIfLevel(WarnLevel)
+	inner.FlushIfLevel(ErrorLevel)
+
+	if logger.Flushed() {
+		t.Fatal("Expected the inner flush to leave the outer logger out of the pool")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output from the inner layer, got %q", buf.String())
+	}
+	logger.FlushIfLevel(WarnLevel)
+
+	expected := "[" + id + "] D: outer message\n" +
+		"[" + id + "] W: inner warning\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithCorrelationToken(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:    "test-123",
+		start: start,
+		buf:   make([]logEntry, 0),
+		w:     &buf,
+	}
+	WithCorrelationToken("")(logger)
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "trace_id=test-123 request_start=2024-01-02T03:04:05Z\n" +
+		"[test-123] D: debug message\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithSkipDebugOnlyFlush(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(l *requestLogger)
+		expected string
+	}{
+		{
+			name: "debug only",
+			log: func(l *requestLogger) {
+				l.Debug("debug message")
+				l.Debug("another debug message")
+			},
+			expected: "[test-123] E: test error\n",
+		},
+		{
+			name: "mixed",
+			log: func(l *requestLogger) {
+				l.Debug("debug message")
+				l.Info("info message")
+			},
+			expected: "[test-123] D: debug message\n[test-123] I: info message\n[test-123] E: test error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+		WithSkipDebugOnlyFlush()(logger)
+
+		tt.log(logger)
+		logger.FlushIf(errors.New("test error"))
+
+		if buf.String() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestWithEagerErrorFlush(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithEagerErrorFlush()(logger)
+
+	logger.Debug("debug message")
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing written before the error, got %q", buf.String())
+	}
+	logger.Error("error message")
+	expected := "[test-123] D: debug message\n[test-123] E: error message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q at the error, got %q", expected, buf.String())
+	}
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+	expected += "[test-123] I: info message\n[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithEagerErrorFlush_Standalone(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WithEagerErrorFlush(), WithGrowthWarn(1), WithIDScheme(fixedID))
+
+	for range 2 {
+		buf.Reset()
+		logger.Debug("debug message")
+		logger.Info("info message")
+		expected := "[test] D: debug message\n[test] I: info message\n[test] W: log buffer exceeded 1 entries\n"
+		if buf.Len() != 0 {
+			t.Fatalf("Expected nothing written before the error, got %q", buf.String())
+		}
+		logger.Error("error message")
+		logger.FlushIf(errors.New("test error"))
+		expected += "[test] E: error message\n[test] E: test error\n"
+		if buf.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, buf.String())
+		}
+	}
+}
+
+func TestWithRootCauseOnly(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("load user: %w", fmt.Errorf("query: %w", fmt.Errorf("dial: %w", root)))
+
+	for _, tt := range []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "[test-123] E: load user: query: dial: connection refused\n"},
+		{[]Option{WithRootCauseOnly()}, "[test-123] E: connection refused\n"},
+	} {
+		var buf bytes.Buffer
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+		for _, opt := range tt.opts {
+			opt(logger)
+		}
+
+		logger.FlushIf(err)
+
+		if buf.String() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+		}
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	var buf, other bytes.Buffer
+	ctx := WithDefaults(context.Background(), WithWriter(&buf), WithName("api"))
+
+	logger := FromContext(WithLogger(ctx))
+	id := logger.ID()
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[" + id + "][api] D: debug message\n[" + id + "][api] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Explicit options override the defaults.
+	logger = FromContext(WithLogger(ctx, WithWriter(&other)))
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+	if other.Len() == 0 || buf.Len() != len(expected) {
+		t.Error("Expected the explicit writer to override the default one")
+	}
+}
+
+func TestWithDeterministic(t *testing.T) {
+	run := func() string {
+		var buf bytes.Buffer
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ctx = WithLogger(ctx, WithWriter(&buf), WithTimestamps(), WithStepTimings(), WithDeterministic())
+		logger := FromContext(ctx)
+		logger.Debug("step one")
+		logger.Info("step two")
+		logger.FlushIf(errors.New("test error"))
+		return buf.String()
+	}
+
+	first, second := run(), run()
+	if first != second {
+		t.Errorf("Expected identical output, got %q and %q", first, second)
+	}
+	expected := "[test] D: step one\n[test] I: step two\n[test] E: test error\n"
+	if first != expected {
+		t.Errorf("Expected %q, got %q", expected, first)
+	}
+}
@@ -0,0 +1,70 @@
+package failtrace
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseLine parses a line in the default text format, "[id][name] L: message",
+// back into an entry and its request ID, e.g. to re-ingest flushed logs or to
+// check them in round-trip tests. The message is everything after the level,
+// so it may contain brackets and colons; persistent fields are part of it.
+// Worker labels and timestamps are skipped, sequence numbers are read back.
+// ok is false if the line does not have the default format.
+func ParseLine(line string) (e Entry, id string, ok bool) {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+	id, rest, ok := cutSegment(line)
+	if !ok || id == "" {
+		return Entry{}, "", false
+	}
+	for strings.HasPrefix(rest, "[") {
+		var seg string
+		if seg, rest, ok = cutSegment(rest); !ok {
+			return Entry{}, "", false
+		}
+		if !strings.HasPrefix(seg, "worker=") {
+			e.Name = seg
+		}
+	}
+
+	rest, ok = strings.CutPrefix(rest, " ")
+	if !ok {
+		return Entry{}, "", false
+	}
+	if seq, after, found := strings.Cut(rest, " "); found && strings.HasPrefix(seq, "#") {
+		// Read the sequence number rendered by WithSequence.
+		if n, err := strconv.ParseUint(seq[1:], 10, 32); err == nil {
+			e.Seq = uint32(n)
+			rest = after
+		}
+	}
+	if !isLevelPrefix(rest) {
+		// Skip the timestamp rendered by WithTimestamps or WithTimeFormat.
+		if _, after, found := strings.Cut(rest, " "); found && isLevelPrefix(after) {
+			rest = after
+		} else {
+			return Entry{}, "", false
+		}
+	}
+	e.Level = Level(rest[0])
+	e.Message = rest[3:]
+	return e, id, true
+}
+
+// cutSegment cuts a leading "[...]" segment off s.
+func cutSegment(s string) (seg, rest string, ok bool) {
+	if !strings.HasPrefix(s, "[") {
+		return "", s, false
+	}
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1:end], s[end+1:], true
+}
+
+// isLevelPrefix reports whether s starts with a level followed by ": ".
+func isLevelPrefix(s string) bool {
+	return len(s) >= 3 && s[0] != ' ' && s[1] == ':' && s[2] == ' '
+}
@@ -0,0 +1,68 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		entry Entry
+		id    string
+	}{
+		{"[test-123] D: debug message\n", Entry{Level: DebugLevel, Message: "debug message"}, "test-123"},
+		{"[test-123][auth] E: test error\n", Entry{Level: ErrorLevel, Message: "test error", Name: "auth"}, "test-123"},
+		{"[test-123][worker=3] I: got [a]: b: c\r\n", Entry{Level: InfoLevel, Message: "got [a]: b: c"}, "test-123"},
+		{"[test-123] 10:00:00.000 W: slow", Entry{Level: WarnLevel, Message: "slow"}, "test-123"},
+	}
+
+	for _, tt := range tests {
+		e, id, ok := ParseLine(tt.line)
+		if !ok {
+			t.Errorf("ParseLine(%q): expected ok", tt.line)
+			continue
+		}
+		if e != tt.entry || id != tt.id {
+			t.Errorf("ParseLine(%q): expected %+v %q, got %+v %q", tt.line, tt.entry, tt.id, e, id)
+		}
+	}
+}
+
+func TestParseLine_Malformed(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"plain text",
+		"[] D: no id",
+		"[test-123 D: unterminated",
+		"[test-123]D: no space",
+		"[test-123] debug message",
+		"[test-123] D:missing space",
+	} {
+		if _, _, ok := ParseLine(line); ok {
+			t.Errorf("ParseLine(%q): expected ok=false", line)
+		}
+	}
+}
+
+func TestParseLine_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	logger.Named("db").Warn("retry [1/3]: timeout")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := []Entry{
+		{Level: WarnLevel, Message: "retry [1/3]: timeout", Name: "db"},
+		{Level: ErrorLevel, Message: "test error"},
+	}
+	for i, line := range strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		e, id, ok := ParseLine(line)
+		if !ok || id != "test-123" || e != expected[i] {
+			t.Errorf("Line %d: expected %+v, got %+v %q %v", i, expected[i], e, id, ok)
+		}
+	}
+}
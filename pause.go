@@ -0,0 +1,13 @@
+package failtrace
+
+// Pause makes logging calls no-ops until Resume, e.g. around a hot inner loop
+// whose entries are not worth their cost. It is cheaper than guarding every
+// call site. Pausing a child scope pauses its whole logger.
+func (l *requestLogger) Pause() {
+	l.owner().paused = true
+}
+
+// Resume restarts buffering after Pause.
+func (l *requestLogger) Resume() {
+	l.owner().paused = false
+}
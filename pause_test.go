@@ -0,0 +1,26 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"io"
+	"testing"
+)
+
+func TestPauseResume(t *testing.T) {
+	logger := New(io.Discard)
+	logger.Debug("before pause")
+
+	logger.Pause()
+	for i := 0; i < 10; i++ {
+		logger.Debugf("iteration %d", i)
+	}
+	logger.Named("db").Info("paused child")
+
+	logger.Resume()
+	logger.Info("after resume")
+
+	if len(logger.buf) != 2 {
+		t.Errorf("Expected 2 buffered entries, got %d", len(logger.buf))
+	}
+}
@@ -0,0 +1,42 @@
+package failtrace
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// sizeWeight is the weight of a new observation in the buffer size estimate.
+const sizeWeight = 0.125
+
+// sizeEstimate holds the float64 bits of an exponentially weighted moving
+// average of buffer lengths at flush. New pooled loggers are presized to it.
+var sizeEstimate atomic.Uint64
+
+// observeSize folds the buffer length n of a flushed request into the estimate.
+func observeSize(n int) {
+	for {
+		old := sizeEstimate.Load()
+		est := math.Float64frombits(old)
+		if old == 0 {
+			est = float64(n)
+		} else {
+			est += sizeWeight * (float64(n) - est)
+		}
+		if sizeEstimate.CompareAndSwap(old, math.Float64bits(est)) {
+			return
+		}
+	}
+}
+
+// bufEstimate returns the current estimate of the buffer length of a request.
+func bufEstimate() int {
+	return int(math.Ceil(math.Float64frombits(sizeEstimate.Load())))
+}
+
+// presizedCap returns the capacity for the buffer of a new pooled logger: the
+// current estimate, clamped between the configured initial capacity and the
+// largest capacity kept by the pool.
+func presizedCap() int {
+	initial := int(bufCap.Load())
+	return min(max(bufEstimate(), initial), maxPooledGrowth*initial)
+}
@@ -0,0 +1,64 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSizeEstimate(t *testing.T) {
+	defer func(est uint64) { sizeEstimate.Store(est) }(sizeEstimate.Load())
+	sizeEstimate.Store(0)
+
+	for i := 0; i < 50; i++ {
+		logger := FromContext(WithLogger(context.Background()))
+		for j := 0; j < 80; j++ {
+			logger.Debug("debug message")
+		}
+		logger.FlushIf(nil)
+	}
+
+	if est := bufEstimate(); est != 80 {
+		t.Errorf("Expected estimate 80, got %d", est)
+	}
+	if got := cap(pool.New().(*requestLogger).buf); got != 80 {
+		t.Errorf("Expected new pooled buffer presized to 80, got %d", got)
+	}
+
+	observeSize(100000)
+	if max := maxPooledGrowth * int(bufCap.Load()); presizedCap() != max {
+		t.Errorf("Expected presized capacity clamped to %d, got %d", max, presizedCap())
+	}
+}
+
+// BenchmarkPresize benchmarks filling a new buffer with a fixed initial
+// capacity against one presized from the warmed-up size estimate.
+func BenchmarkPresize(b *testing.B) {
+	const entries = 100
+	defer func(est uint64) { sizeEstimate.Store(est) }(sizeEstimate.Load())
+
+	fill := func(buf []logEntry) {
+		for i := 0; i < entries; i++ {
+			buf = append(buf, logEntry{level: DebugLevel, message: "debug message"})
+		}
+	}
+
+	b.Run("Fixed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fill(make([]logEntry, 0, bufCap.Load()))
+		}
+	})
+
+	sizeEstimate.Store(0)
+	for i := 0; i < 50; i++ {
+		observeSize(entries)
+	}
+	b.Run("Adaptive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fill(make([]logEntry, 0, presizedCap()))
+		}
+	})
+}
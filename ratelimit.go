@@ -0,0 +1,142 @@
+package failtrace
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// afterFunc schedules the roll-over report of a rate limit window; tests
+// replace it to fire the report by hand.
+var afterFunc = time.AfterFunc
+
+// rateLimiter is a token bucket per error key, shared by all loggers created
+// with the same WithRateLimit option.
+type rateLimiter struct {
+	mu        sync.Mutex
+	key       func(err error) string
+	perMinute float64
+	buckets   map[string]*bucket
+	swept     time.Time
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+	// report is set while a roll-over report is scheduled. It holds the
+	// options of the first suppressed flush of the window and the ID of the
+	// last one, which the report is written with.
+	report *requestLogger
+}
+
+// WithRateLimit limits error flushes to perMinute per key, where key groups
+// similar errors (e.g. by err.Error()). Flushes over the limit are suppressed;
+// when the key's window rolls over, a single "suppressed N similar errors"
+// line is written with the ID of the last suppressed request and the options
+// of the first one, or carried by the next allowed flush if it comes first.
+// The roll-over line is written from a timer goroutine, so the writer must be
+// safe for concurrent use, as it already is when shared by concurrent
+// requests. Flushes without an error are never limited. Keys idle for a
+// minute are forgotten.
+//
+// The limiter state lives in the returned option, so create it once and pass
+// it to every WithLogger call:
+//
+//	limit := failtrace.WithRateLimit(func(err error) string { return err.Error() }, 10)
+//	ctx = failtrace.WithLogger(ctx, limit)
+func WithRateLimit(key func(err error) string, perMinute int) Option {
+	rl := &rateLimiter{
+		key:       key,
+		perMinute: float64(perMinute),
+		buckets:   make(map[string]*bucket),
+	}
+	return func(l *requestLogger) {
+		l.limit = rl
+	}
+}
+
+// allow reports whether a flush of l for err at time t may be written, and
+// how many flushes for the same key were suppressed and not yet reported.
+func (r *rateLimiter) allow(l *requestLogger, err error, t time.Time) (bool, int) {
+	k := r.key(err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(t)
+
+	b, ok := r.buckets[k]
+	if !ok {
+		b = &bucket{tokens: r.perMinute, last: t}
+		r.buckets[k] = b
+	}
+
+	b.tokens += t.Sub(b.last).Minutes() * r.perMinute
+	if b.tokens > r.perMinute {
+		b.tokens = r.perMinute
+	}
+	b.last = t
+
+	if b.tokens < 1 {
+		b.suppressed++
+		if b.report != nil {
+			b.report.id = l.ID()
+		} else {
+			b.report = l.reporter()
+			wait := time.Duration((1 - b.tokens) / r.perMinute * float64(time.Minute))
+			afterFunc(wait, func() { r.report(b) })
+		}
+		return false, 0
+	}
+
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+// report writes the suppressed count of b when its window rolls over, unless
+// an allowed flush has carried it already.
+func (r *rateLimiter) report(b *bucket) {
+	r.mu.Lock()
+	suppressed, l := b.suppressed, b.report
+	b.suppressed, b.report = 0, nil
+	r.mu.Unlock()
+
+	if suppressed == 0 {
+		return
+	}
+	l.write(nil, nil, nil, l.synth(WarnLevel, fmt.Sprintf("suppressed %d similar errors", suppressed)))
+}
+
+// reporter returns a detached copy of l holding only its ID and options, so
+// the roll-over report is rendered like the suppressed flushes once l is back
+// in the pool.
+func (l *requestLogger) reporter() *requestLogger {
+	l.ID()
+	d := *l
+	d.buf, d.meta, d.names, d.attachments, d.hooks = nil, nil, nil, nil, nil
+	d.counts = [len(countedLevels)]uint32{}
+	d.fields = append(l.fields[:0:0], l.fields...)
+	d.outputs = append(l.outputs[:0:0], l.outputs...)
+	d.streams = append(l.streams[:0:0], l.streams...)
+	d.limit, d.cooldown, d.async = nil, nil, nil
+	d.detached = true
+	d.leakCheck = false
+	return &d
+}
+
+// sweep forgets the buckets idle for a minute, at most once a minute. An idle
+// bucket has refilled, so dropping it does not change what is allowed.
+func (r *rateLimiter) sweep(t time.Time) {
+	if t.Sub(r.swept) < time.Minute {
+		return
+	}
+	r.swept = t
+	for k, b := range r.buckets {
+		if b.report == nil && t.Sub(b.last) >= time.Minute {
+			delete(r.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	afterFunc = func(time.Duration, func()) *time.Timer { return nil }
+	defer func() { afterFunc = time.AfterFunc }()
+
+	var buf bytes.Buffer
+	limit := WithRateLimit(func(err error) string { return err.Error() }, 2)
+	flush := func(err error) {
+		logger := &requestLogger{
+			id:  "test-123",
+			buf: make([]logEntry, 0),
+			w:   &buf,
+		}
+		limit(logger)
+		logger.Debug("debug message")
+		logger.FlushIf(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		flush(errors.New("dependency down"))
+	}
+	flush(errors.New("other error"))
+
+	if n := strings.Count(buf.String(), "E: dependency down"); n != 2 {
+		t.Errorf("Expected 2 flushes before suppression, got %d", n)
+	}
+	if n := strings.Count(buf.String(), "E: other error"); n != 1 {
+		t.Errorf("Expected unrelated error to be flushed, got %d", n)
+	}
+
+	buf.Reset()
+	clock = clock.Add(time.Minute)
+	flush(errors.New("dependency down"))
+
+	expected := "[test-123] W: suppressed 3 similar errors\n" +
+		"[test-123] D: debug message\n" +
+		"[test-123] E: dependency down\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithRateLimit_RollOver(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var reports []func()
+	var waits []time.Duration
+	afterFunc = func(d time.Duration, f func()) *time.Timer {
+		waits = append(waits, d)
+		reports = append(reports, f)
+		return nil
+	}
+	defer func() { afterFunc = time.AfterFunc }()
+
+	var buf bytes.Buffer
+	limit := WithRateLimit(func(err error) string { return err.Error() }, 2)
+	for i := 0; i < 5; i++ {
+		logger := &requestLogger{id: fmt.Sprintf("req-%d", i), buf: make([]logEntry, 0), w: &buf}
+		limit(logger)
+		logger.FlushIf(errors.New("dependency down"))
+	}
+
+	if len(reports) != 1 {
+		t.Fatalf("Expected one report scheduled for the window, got %d", len(reports))
+	}
+	if waits[0] != 30*time.Second {
+		t.Errorf("Expected the report when a token is back, got %v", waits[0])
+	}
+
+	buf.Reset()
+	reports[0]()
+	expected := "[req-4] W: suppressed 3 similar errors\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	clock = clock.Add(time.Minute)
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	limit(logger)
+	logger.FlushIf(errors.New("dependency down"))
+	if strings.Contains(buf.String(), "suppressed") {
+		t.Errorf("Expected the count to be reported once, got %q", buf.String())
+	}
+}
+
+func TestWithRateLimit_EvictsIdle(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	limit := WithRateLimit(func(err error) string { return err.Error() }, 2)
+	var rl *rateLimiter
+	flush := func(err error) {
+		logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: io.Discard}
+		limit(logger)
+		rl = logger.limit
+		logger.FlushIf(err)
+	}
+	for i := 0; i < 100; i++ {
+		flush(fmt.Errorf("error %d", i))
+	}
+
+	clock = clock.Add(time.Minute)
+	flush(errors.New("late error"))
+	if n := len(rl.buckets); n != 1 {
+		t.Errorf("Expected idle buckets to be evicted, got %d", n)
+	}
+}
+
+func TestWithRateLimit_RollOverOptions(t *testing.T) {
+	var reports []func()
+	afterFunc = func(d time.Duration, f func()) *time.Timer {
+		reports = append(reports, f)
+		return nil
+	}
+	defer func() { afterFunc = time.AfterFunc }()
+
+	var buf bytes.Buffer
+	limit := WithRateLimit(func(err error) string { return err.Error() }, 1)
+	for i := 0; i < 3; i++ {
+		logger := FromContext(WithLogger(context.Background(), WithWriter(&buf), WithJSON(), WithServiceFields("api", "prod", "1.0"), limit))
+		logger.FlushIf(errors.New("dependency down"))
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected one report scheduled for the window, got %d", len(reports))
+	}
+
+	buf.Reset()
+	reports[0]()
+	var e map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", buf.String(), err)
+	}
+	if e["message"] != "suppressed 2 similar errors" || e["service"] != "api" || e["id"] == "" {
+		t.Errorf("Expected the report rendered with the logger options, got %q", buf.String())
+	}
+}
@@ -0,0 +1,30 @@
+package failtrace
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Recover writes the trace of l when the surrounding function panics. It must
+// be deferred directly. On a panic it logs the panic value and stack as an
+// error entry, flushes the buffer with the panic as the flush error, and
+// panics again with the same value so the crash is preserved. It does nothing
+// if there is no panic.
+//
+// Usage example:
+//
+//	log := failtrace.FromContext(ctx)
+//	defer failtrace.Recover(log)
+func Recover(l *requestLogger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", r)
+	}
+	l.Error(fmt.Sprintf("panic: %v\n%s", r, debug.Stack()))
+	l.FlushIf(err)
+	panic(r)
+}
@@ -0,0 +1,53 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf, detached: true}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("Expected the panic to be re-raised, got %v", r)
+		}
+		output := buf.String()
+		if !strings.HasPrefix(output, "[test-123] D: before panic\n[test-123] E: panic: boom\ngoroutine ") {
+			t.Errorf("Expected the trace and the panic entry, got %q", output)
+		}
+		if !strings.Contains(output, "TestRecover") {
+			t.Errorf("Expected the panic stack, got %q", output)
+		}
+		if !strings.HasSuffix(output, "[test-123] E: panic: boom\n") {
+			t.Errorf("Expected the panic as the error line, got %q", output)
+		}
+	}()
+
+	func() {
+		defer Recover(logger)
+		logger.Debug("before panic")
+		panic("boom")
+	}()
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+
+	func() {
+		defer Recover(logger)
+		logger.Debug("no panic")
+	}()
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+	if len(logger.buf) != 1 {
+		t.Errorf("Expected the buffer to be kept, got %d entries", len(logger.buf))
+	}
+}
@@ -0,0 +1,48 @@
+package failtrace
+
+import "sync"
+
+// RingWriter is an io.Writer retaining the last flushed request traces in
+// memory, e.g. to serve them from a debug endpoint. Loggers hand each flush
+// to their writer in a single Write call, so every Write is kept as one
+// trace. It is safe for concurrent use.
+type RingWriter struct {
+	mu     sync.Mutex
+	traces []string
+	next   int
+	full   bool
+}
+
+// NewRingWriter returns a RingWriter keeping the last capacity flushes.
+func NewRingWriter(capacity int) *RingWriter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingWriter{traces: make([]string, capacity)}
+}
+
+// Write stores p as one flushed trace, evicting the oldest if the ring is full.
+func (r *RingWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.traces[r.next] = string(p)
+	r.next = (r.next + 1) % len(r.traces)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Dump returns the retained traces, oldest first.
+func (r *RingWriter) Dump() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]string(nil), r.traces[:r.next]...)
+	}
+	dump := make([]string, 0, len(r.traces))
+	dump = append(dump, r.traces[r.next:]...)
+	return append(dump, r.traces[:r.next]...)
+}
@@ -0,0 +1,45 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingWriter(t *testing.T) {
+	ring := NewRingWriter(2)
+
+	for i := 0; i < 4; i++ {
+		logger := FromContext(WithLogger(context.Background(), WithWriter(ring)))
+		logger.Debugf("request %d", i)
+		logger.Info("second line")
+		logger.FlushIf(errors.New("test error"))
+	}
+
+	dump := ring.Dump()
+	if len(dump) != 2 {
+		t.Fatalf("Expected 2 retained flushes, got %d", len(dump))
+	}
+	for i, trace := range dump {
+		if n := strings.Count(trace, "\n"); n != 3 {
+			t.Errorf("Trace %d: expected 3 lines, got %d", i, n)
+		}
+		if want := fmt.Sprintf("D: request %d\n", i+2); !strings.Contains(trace, want) {
+			t.Errorf("Trace %d: expected %q, got %q", i, want, trace)
+		}
+	}
+}
+
+func TestRingWriter_NotFull(t *testing.T) {
+	ring := NewRingWriter(3)
+	fmt.Fprint(ring, "first")
+
+	dump := ring.Dump()
+	if len(dump) != 1 || dump[0] != "first" {
+		t.Errorf("Expected only the written trace, got %q", dump)
+	}
+}
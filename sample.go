@@ -0,0 +1,25 @@
+package failtrace
+
+import "math/rand/v2"
+
+// sampleFloat returns a number in [0, 1) deciding whether a successful request
+// is sampled in. Tests replace it with a seeded source.
+var sampleFloat = rand.Float64
+
+// WithSampleSuccess writes the buffer of a successful request (FlushIf(nil))
+// with probability rate, as Flush would, so that a representative sample of
+// healthy traces is kept. Error flushes are always written.
+func WithSampleSuccess(rate float64) Option {
+	return func(l *requestLogger) {
+		l.sampleRate = rate
+	}
+}
+
+// sampled reports whether a successful request should be written anyway.
+func (l *requestLogger) sampled() bool {
+	rate := l.sampleRate
+	if l.adaptive {
+		rate = max(rate, adaptiveFloor, errorRatio.observe(false, l.now()))
+	}
+	return rate > 0 && sampleFloat() < rate
+}
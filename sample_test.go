@@ -0,0 +1,50 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestWithSampleSuccess(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sampleFloat = r.Float64
+	defer func() { sampleFloat = rand.Float64 }()
+
+	// Replay the seeded sequence to know which requests are sampled in.
+	want := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 20; i++ {
+		var buf bytes.Buffer
+		logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+		WithSampleSuccess(0.5)(logger)
+
+		logger.Info("info message")
+		logger.FlushIf(nil)
+
+		sampledIn := want.Float64() < 0.5
+		if got := buf.String() != ""; got != sampledIn {
+			t.Errorf("Request %d: expected sampled in %v, got output %q", i, sampledIn, buf.String())
+		}
+	}
+}
+
+func TestWithSampleSuccessErrorAlwaysWritten(t *testing.T) {
+	sampleFloat = func() float64 { return 0.99 }
+	defer func() { sampleFloat = rand.Float64 }()
+
+	var buf bytes.Buffer
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: &buf}
+	WithSampleSuccess(0.01)(logger)
+
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	if !strings.Contains(buf.String(), "E: test error") {
+		t.Errorf("Expected error flush to be written, got %q", buf.String())
+	}
+}
@@ -0,0 +1,20 @@
+package failtrace
+
+import "context"
+
+// Scope installs a logger configured by opts, runs fn with the new context and
+// flushes the logger according to the error fn returns: the trace is written
+// if it is not nil and discarded otherwise. It returns the error of fn.
+//
+// Usage example:
+//
+//	err := failtrace.Scope(ctx, func(ctx context.Context) error {
+//		failtrace.FromContext(ctx).Debug("handling request")
+//		return handle(ctx)
+//	})
+func Scope(ctx context.Context, fn func(context.Context) error, opts ...Option) error {
+	ctx = WithLogger(ctx, opts...)
+	err := fn(ctx)
+	FromContext(ctx).FlushIf(err)
+	return err
+}
@@ -0,0 +1,43 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	var buf bytes.Buffer
+	var id string
+	testErr := errors.New("test error")
+
+	err := Scope(context.Background(), func(ctx context.Context) error {
+		id, _ = IDFromContext(ctx)
+		FromContext(ctx).Debug("debug message")
+		return testErr
+	}, WithWriter(&buf))
+
+	if err != testErr {
+		t.Errorf("Expected %v, got %v", testErr, err)
+	}
+	expected := "[" + id + "] D: debug message\n[" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	err = Scope(context.Background(), func(ctx context.Context) error {
+		FromContext(ctx).Debug("debug message")
+		return nil
+	}, WithWriter(&buf))
+
+	if err != nil {
+		t.Errorf("Expected nil error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,28 @@
+package failtrace
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// WithSequence numbers the entries of a request in the order they are logged,
+// starting at 1, and renders the number on every flushed line, as
+// "[id] #0003 D: msg", or as a "seq" key in JSON mode. Collectors that reorder
+// lines can then restore the original order. Lines synthesized at flush time,
+// such as the error line, carry no number.
+func WithSequence() Option {
+	return func(l *requestLogger) {
+		l.sequence = true
+	}
+}
+
+// writeSeq renders "#" and seq padded to four digits, then a space, into b.
+func writeSeq(b *bytes.Buffer, seq uint32) {
+	b.WriteByte('#')
+	n := strconv.FormatUint(uint64(seq), 10)
+	for i := len(n); i < 4; i++ {
+		b.WriteByte('0')
+	}
+	b.WriteString(n)
+	b.WriteByte(' ')
+}
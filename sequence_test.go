@@ -0,0 +1,46 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithSequence(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), WithWriter(&buf), WithSequence(), WithDeterministic())
+	logger := FromContext(ctx)
+	logger.Debug("step one")
+	logger.Named("db").Info("step two")
+	logger.Warn("step three")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test] #0001 D: step one\n" +
+		"[test][db] #0002 I: step two\n" +
+		"[test] #0003 W: step three\n" +
+		"[test] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// The sequence starts over when the logger is reused from the pool.
+	buf.Reset()
+	logger = FromContext(WithLogger(context.Background(), WithWriter(&buf), WithSequence(), WithDeterministic()))
+	logger.Debug("step one")
+	logger.FlushIf(errors.New("test error"))
+
+	expected = "[test] #0001 D: step one\n[test] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestParseLineSequence(t *testing.T) {
+	e, id, ok := ParseLine("[test][db] #0012 I: step two")
+	if !ok || id != "test" || e.Seq != 12 || e.Level != InfoLevel || e.Message != "step two" {
+		t.Errorf("Expected sequence 12 to be parsed, got %+v %q %v", e, id, ok)
+	}
+}
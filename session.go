@@ -0,0 +1,50 @@
+package failtrace
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Session groups the loggers of related requests, e.g. the steps of a saga,
+// under a common ID prefix so they can be flushed together.
+type Session struct {
+	mu       sync.Mutex
+	id       string
+	w        io.Writer
+	n        int
+	children []*requestLogger
+}
+
+// NewSession returns a session writing to w whose loggers carry IDs prefixed
+// with id.
+func NewSession(w io.Writer, id string) *Session {
+	return &Session{id: id, w: w}
+}
+
+// Logger returns a new standalone logger tracked by the session. Its ID is
+// the session ID followed by a sequence number, e.g. "saga-1/2".
+func (s *Session) Logger(opts ...Option) *requestLogger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.n++
+	l := New(s.w, opts...)
+	l.id = s.id + "/" + strconv.Itoa(s.n)
+	s.children = append(s.children, l)
+	return l
+}
+
+// FlushAll calls FlushIf(err) on every logger handed out since the last
+// FlushAll, in creation order, and stops tracking them. The loggers must not
+// be in use concurrently.
+func (s *Session) FlushAll(err error) {
+	s.mu.Lock()
+	children := s.children
+	s.children = nil
+	s.mu.Unlock()
+
+	for _, l := range children {
+		l.FlushIf(err)
+	}
+}
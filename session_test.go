@@ -0,0 +1,37 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSession_FlushAll(t *testing.T) {
+	var buf bytes.Buffer
+	session := NewSession(&buf, "saga-1")
+
+	reserve := session.Logger()
+	charge := session.Logger()
+	reserve.Info("reserving stock")
+	charge.Info("charging card")
+	charge.Warn("card declined")
+
+	session.FlushAll(errors.New("saga failed"))
+
+	expected := "[saga-1/1] I: reserving stock\n" +
+		"[saga-1/1] E: saga failed\n" +
+		"[saga-1/2] I: charging card\n" +
+		"[saga-1/2] W: card declined\n" +
+		"[saga-1/2] E: saga failed\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	session.FlushAll(errors.New("saga failed"))
+	if buf.String() != "" {
+		t.Errorf("Expected no output for already flushed loggers, got %q", buf.String())
+	}
+}
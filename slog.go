@@ -0,0 +1,52 @@
+package failtrace
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithSlogSink replays buffered entries into sl on flush instead of writing
+// formatted text, leaving formatting and destination to the slog handler.
+// Each entry becomes one record carrying the request ID, and the error of
+// FlushIf is logged at its flush level.
+func WithSlogSink(sl *slog.Logger) Option {
+	return func(l *requestLogger) {
+		l.slog = sl
+	}
+}
+
+// replay logs the lead, buffered and trailing entries to the slog sink.
+func (l *requestLogger) replay(entries []logEntry, lead, trail []Entry) {
+	id := l.ID()
+	for _, e := range lead {
+		l.logAttrs(id, e)
+	}
+	for _, e := range entries {
+		l.logAttrs(id, l.entry(e))
+	}
+	for _, e := range trail {
+		l.logAttrs(id, e)
+	}
+}
+
+func (l *requestLogger) logAttrs(id string, e Entry) {
+	attrs := []slog.Attr{slog.String("id", id)}
+	if e.Name != "" {
+		attrs = append(attrs, slog.String("name", e.Name))
+	}
+	l.slog.LogAttrs(context.Background(), slogLevel(e.Level), e.Message, attrs...)
+}
+
+// slogLevel maps a failtrace level to a slog level.
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,63 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+type captureHandler struct {
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+func TestWithSlogSink(t *testing.T) {
+	h := &captureHandler{}
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+	}
+	WithSlogSink(slog.New(h))(logger)
+
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := []struct {
+		level slog.Level
+		msg   string
+	}{
+		{slog.LevelDebug, "debug message"},
+		{slog.LevelWarn, "warn message"},
+		{slog.LevelError, "test error"},
+	}
+
+	if len(h.records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(h.records))
+	}
+	for i, e := range expected {
+		r := h.records[i]
+		if r.Level != e.level || r.Message != e.msg {
+			t.Errorf("Record %d: expected %v %q, got %v %q", i, e.level, e.msg, r.Level, r.Message)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "id" && a.Value.String() != "test-123" {
+				t.Errorf("Record %d: expected id 'test-123', got '%s'", i, a.Value)
+			}
+			return true
+		})
+	}
+}
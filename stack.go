@@ -0,0 +1,50 @@
+package failtrace
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// pkgDir is the source directory of the package, whose frames are left out of
+// flush stacks.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// WithFlushStack appends the stack of the FlushIf call site, up to depth
+// frames, after the error line of error flushes, to show where the error
+// surfaced. Frames inside failtrace itself are skipped. Each frame is rendered
+// as an entry "  at pkg.Func (file:line)" at the level of the error line.
+func WithFlushStack(depth int) Option {
+	return func(l *requestLogger) {
+		l.stackDepth = depth
+	}
+}
+
+// callerStack returns up to depth frames of the current goroutine outside of
+// the package, rendered as entries at level.
+func (l *requestLogger) callerStack(level Level, depth int) []Entry {
+	pcs := make([]uintptr, depth+32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+
+	var stack []Entry
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < depth {
+		f, more := frames.Next()
+		if !internalFrame(f.File) {
+			stack = append(stack, l.synth(level, fmt.Sprintf("  at %s (%s:%d)", f.Function, f.File, f.Line)))
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// internalFrame reports whether file is a non-test source file of failtrace.
+func internalFrame(file string) bool {
+	return filepath.Dir(file) == pkgDir && !strings.HasSuffix(file, "_test.go")
+}
@@ -0,0 +1,41 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func failingHandler(logger *requestLogger) {
+	logger.FlushIf(errors.New("test error"))
+}
+
+func TestWithFlushStack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithFlushStack(2)(logger)
+
+	logger.Debug("debug message")
+	failingHandler(logger)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected debug, error and 2 stack lines, got %q", buf.String())
+	}
+	if lines[1] != "[test-123] E: test error" {
+		t.Errorf("Expected the error line before the stack, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "[test-123] E:   at github.com/IbrahimShahzad/failtrace.failingHandler (") {
+		t.Errorf("Expected the caller as first frame, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "failtrace.TestWithFlushStack") {
+		t.Errorf("Expected the test as second frame, got %q", lines[3])
+	}
+}
@@ -0,0 +1,128 @@
+package failtrace
+
+import (
+	"io"
+	"os"
+	"slices"
+)
+
+// stdout and stderr are the streams used by WithStdStreams; replaced in tests.
+var stdout, stderr io.Writer = os.Stdout, os.Stderr
+
+// levelStream is a writer added by WithLevelWriter with its levels.
+type levelStream struct {
+	w      io.Writer
+	levels []Level
+}
+
+// WithLevelWriter sends the flushed lines of the given levels to w instead of
+// the writer set by WithWriter. Lines of a flush going to the same writer are
+// still written in a single call, in their original order, so pass all the
+// levels of a writer in one call. A later call takes over the levels it names.
+func WithLevelWriter(w io.Writer, levels ...Level) Option {
+	return func(l *requestLogger) {
+		l.streams = append(l.streams, levelStream{w: w, levels: levels})
+	}
+}
+
+// WithStdStreams follows the Unix convention for CLI tools: debug and info
+// lines go to os.Stdout, while warnings, errors and the error line of a flush
+// go to os.Stderr.
+func WithStdStreams() Option {
+	return func(l *requestLogger) {
+		l.w = stderr
+		WithLevelWriter(stdout, DebugLevel, InfoLevel)(l)
+	}
+}
+
+// streamIndex returns the 1-based index of the stream of the lines of the
+// given level, or 0 for the writer set by WithWriter. Streams are told apart
+// by index, as writers need not be comparable.
+func (l *requestLogger) streamIndex(level Level) int {
+	for i := len(l.streams) - 1; i >= 0; i-- {
+		if slices.Contains(l.streams[i].levels, level) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// stream returns the writer of the lines of the given level.
+func (l *requestLogger) stream(level Level) io.Writer {
+	if i := l.streamIndex(level); i > 0 {
+		return l.streams[i-1].w
+	}
+	return l.w
+}
+
+// writeStreams splits the entries by the stream of their level and writes
+// each part, skipping streams that get no entries. The flush error err goes
+// with the part holding the error line. It returns the total number of bytes
+// written and the first write error.
+func (l *requestLogger) writeStreams(err error, entries []logEntry, lead []Entry, trail ...Entry) (int, error) {
+	errIdx := l.streamIndex(ErrorLevel)
+	if len(trail) > 0 {
+		errIdx = l.streamIndex(trail[0].Level)
+	}
+
+	var (
+		total    int
+		firstErr error
+		written  bool
+	)
+	for i := 0; i <= len(l.streams); i++ {
+		part, partLead, partTrail := l.entriesFor(i, entries), l.linesFor(i, lead), l.linesFor(i, trail)
+		if len(part) == 0 && len(partLead) == 0 && len(partTrail) == 0 {
+			continue
+		}
+		written = true
+		var partErr error
+		if i == errIdx {
+			partErr = err
+		}
+		n, werr := l.writeStream(i, partErr, part, partLead, partTrail...)
+		total += n
+		if firstErr == nil {
+			firstErr = werr
+		}
+	}
+	if !written {
+		// An empty flush still goes to the default stream, e.g. "[]" in
+		// JSON array mode.
+		return l.writeStream(0, err, nil, nil)
+	}
+	return total, firstErr
+}
+
+// writeStream writes a part of a flush to the stream at index i, 0 being the
+// default writer.
+func (l *requestLogger) writeStream(i int, err error, entries []logEntry, lead []Entry, trail ...Entry) (int, error) {
+	c := *l
+	c.w, c.streams = l.w, nil
+	if i > 0 {
+		c.w = l.streams[i-1].w
+	}
+	return c.write(err, entries, lead, trail...)
+}
+
+// entriesFor returns the buffered entries written to the stream at index i.
+func (l *requestLogger) entriesFor(i int, entries []logEntry) []logEntry {
+	var part []logEntry
+	for _, e := range entries {
+		if l.streamIndex(e.level) == i {
+			part = append(part, e)
+		}
+	}
+	return part
+}
+
+// linesFor returns the synthesized entries written to the stream at index i.
+func (l *requestLogger) linesFor(i int, entries []Entry) []Entry {
+	var part []Entry
+	for _, e := range entries {
+		if l.streamIndex(e.Level) == i {
+			part = append(part, e)
+		}
+	}
+	return part
+}
@@ -0,0 +1,98 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWithStdStreams(t *testing.T) {
+	var out, errOut bytes.Buffer
+	oldOut, oldErr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	defer func() { stdout, stderr = oldOut, oldErr }()
+
+	logger := New(nil, WithStdStreams())
+	logger.id = "test-123"
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+	logger.Info("info message")
+	logger.Error("error message")
+	logger.FlushIf(errors.New("test error"))
+
+	expectedOut := "[test-123] D: debug message\n[test-123] I: info message\n"
+	if out.String() != expectedOut {
+		t.Errorf("Expected stdout %q, got %q", expectedOut, out.String())
+	}
+	expectedErr := "[test-123] W: warn message\n[test-123] E: error message\n[test-123] E: test error\n"
+	if errOut.String() != expectedErr {
+		t.Errorf("Expected stderr %q, got %q", expectedErr, errOut.String())
+	}
+}
+
+func TestWithLevelWriterPassthrough(t *testing.T) {
+	var buf, warnings bytes.Buffer
+	logger := New(&buf, WithPassthrough(), WithLevelWriter(&warnings, WarnLevel))
+	logger.id = "test-123"
+	logger.Info("info message")
+	logger.Warn("warn message")
+
+	if expected := "[test-123] I: info message\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if expected := "[test-123] W: warn message\n"; warnings.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, warnings.String())
+	}
+}
+
+// sliceWriter is a valid writer whose dynamic type is not comparable.
+type sliceWriter []*bytes.Buffer
+
+func (w sliceWriter) Write(p []byte) (int, error) {
+	for _, b := range w {
+		b.Write(p)
+	}
+	return len(p), nil
+}
+
+func TestWithLevelWriterUncomparable(t *testing.T) {
+	var buf, debug bytes.Buffer
+	logger := New(sliceWriter{&buf}, WithLevelWriter(sliceWriter{&debug}, DebugLevel))
+	logger.id = "test-123"
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+
+	if expected := "[test-123] D: debug message\n"; debug.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, debug.String())
+	}
+	if expected := "[test-123] I: info message\n[test-123] E: test error\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithLevelWriterJSONArray(t *testing.T) {
+	var buf, warnings bytes.Buffer
+	logger := New(&buf, WithJSONArray(), WithLevelWriter(&warnings, WarnLevel))
+	logger.id = "test-123"
+	logger.Info("info message")
+	logger.Flush()
+
+	if expected := `[{"id":"test-123","level":"I","message":"info message"}]` + "\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected nothing on a stream without entries, got %q", warnings.String())
+	}
+
+	buf.Reset()
+	logger.Flush()
+	if expected := "[]\n"; buf.String() != expected {
+		t.Errorf("Expected an empty flush on the default stream, got %q", buf.String())
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("Expected nothing on a stream without entries, got %q", warnings.String())
+	}
+}
@@ -0,0 +1,66 @@
+package failtrace
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Syncer is implemented by writers that can commit written data to stable
+// storage. A flush calls Sync after its batched write, so the trace is durable
+// before the flush returns.
+//
+// *os.File is deliberately not synced, as fsync fails on terminals and pipes
+// such as os.Stderr; use NewSyncFileWriter for durable files.
+type Syncer interface {
+	Sync() error
+}
+
+// syncWriter syncs w if it is a Syncer other than *os.File.
+func syncWriter(w io.Writer) error {
+	if _, ok := w.(*os.File); ok {
+		return nil
+	}
+	if s, ok := w.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// syncFileWriter is an append-only file synced after every flush.
+type syncFileWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewSyncFileWriter opens path for appending, creating it if needed, and
+// returns a writer whose flushes are on disk before FlushIf returns, for
+// crash-critical audit logs. It is safe for concurrent flushes from many
+// loggers. The caller must Close it.
+func NewSyncFileWriter(path string) (io.WriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &syncFileWriter{f: f}, nil
+}
+
+func (w *syncFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Write(p)
+}
+
+// Sync commits the file to stable storage.
+func (w *syncFileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Sync()
+}
+
+// Close closes the file.
+func (w *syncFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
@@ -0,0 +1,67 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type syncCounter struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (s *syncCounter) Sync() error {
+	s.syncs++
+	return nil
+}
+
+func TestSyncer(t *testing.T) {
+	w := &syncCounter{}
+	logger := &requestLogger{id: "test-123", buf: make([]logEntry, 0), w: w}
+
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	if w.syncs != 1 {
+		t.Errorf("Expected 1 sync after the flush, got %d", w.syncs)
+	}
+}
+
+func TestNewSyncFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	w, err := NewSyncFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file is deliberately left open until the test ends, as if the
+	// process crashed right after the flush.
+	t.Cleanup(func() { w.Close() })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := New(w)
+			logger.id = "test-123"
+			logger.Debug("debug message")
+			logger.FlushIf(errors.New("test error"))
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := bytes.Repeat([]byte("[test-123] D: debug message\n[test-123] E: test error\n"), 10)
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
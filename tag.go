@@ -0,0 +1,69 @@
+package failtrace
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// tags maps tag names to the 1-based IDs stored in entries. Tags are only ever
+// added, like interned messages.
+var tags struct {
+	mu  sync.Mutex
+	ids map[string]uint16
+}
+
+// tagID returns the ID of tag, registering it if add is set. It returns 0 for
+// unknown tags, or once all IDs are taken.
+func tagID(tag string, add bool) uint16 {
+	tags.mu.Lock()
+	defer tags.mu.Unlock()
+	if id, ok := tags.ids[tag]; ok || !add {
+		return id
+	}
+	if len(tags.ids) == math.MaxUint16 {
+		return 0
+	}
+	if tags.ids == nil {
+		tags.ids = make(map[string]uint16)
+	}
+	id := uint16(len(tags.ids) + 1)
+	tags.ids[tag] = id
+	return id
+}
+
+// Tagged returns a child scope of the logger whose entries carry tag, e.g.
+// "db" or "cache", so that FlushTagged can write only the entries of some
+// tags. Like Named, the child writes into the same buffer, and tags are not
+// rendered. An entry carries a single tag: tagging a tagged scope replaces
+// its tag.
+func (l *requestLogger) Tagged(tag string) *requestLogger {
+	return &requestLogger{
+		w:         l.w,
+		name:      l.name,
+		with:      l.with,
+		tag:       tagID(tag, true),
+		root:      l.owner(),
+		inherited: l.inherited,
+	}
+}
+
+// FlushTagged behaves like FlushIf, but writes only the buffered entries
+// carrying one of the given tags, followed by the error line, for focused
+// debugging. Untagged entries are dropped.
+func (l *requestLogger) FlushTagged(err error, tags ...string) {
+	o := l.owner()
+	if l.inherited || o.flushed {
+		return
+	}
+	ids := make([]uint16, 0, len(tags))
+	for _, tag := range tags {
+		if id := tagID(tag, false); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	o.buf = slices.DeleteFunc(o.buf, func(e logEntry) bool {
+		return e.tag == 0 || !slices.Contains(ids, e.tag)
+	})
+	o.flushIf(ErrorLevel, err, nil)
+}
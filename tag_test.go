@@ -0,0 +1,34 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFlushTagged(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+
+	db, cache := logger.Tagged("db"), logger.Tagged("cache")
+	logger.Debug("untagged message")
+	db.Debug("query users")
+	cache.Info("cache miss")
+	db.Named("tx").Warn("slow commit")
+	logger.Tagged("http").Info("request received")
+
+	logger.FlushTagged(errors.New("test error"), "db", "unknown")
+
+	expected := "[test-123] D: query users\n" +
+		"[test-123][tx] W: slow commit\n" +
+		"[test-123] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,55 @@
+package failtrace
+
+import (
+	"strings"
+	"time"
+)
+
+// WithTimestamps records the time of every entry and renders it after the
+// request ID of each flushed line, as RFC 3339 with nanoseconds.
+func WithTimestamps() Option {
+	return func(l *requestLogger) {
+		l.stamp = true
+		l.timeLayout = time.RFC3339Nano
+	}
+}
+
+// WithTimeFormat renders entry timestamps using the given time layout, e.g.
+// "15:04:05.000", instead of RFC 3339. An empty layout disables rendering
+// timestamps.
+func WithTimeFormat(layout string) Option {
+	return func(l *requestLogger) {
+		l.stamp = l.stamp || layout != ""
+		l.timeLayout = layout
+	}
+}
+
+// WithStepTimings records the time of every entry and appends a trailing
+// "timings: a->b 1.2ms b->c 300µs" line to each flush, listing the time
+// between consecutive entries labeled by their messages.
+func WithStepTimings() Option {
+	return func(l *requestLogger) {
+		l.stamp = true
+		l.timings = true
+	}
+}
+
+// stepTimings renders the deltas between consecutive entries.
+func (l *requestLogger) stepTimings(entries []logEntry) (Entry, bool) {
+	if len(entries) < 2 {
+		return Entry{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("timings:")
+	for i := 1; i < len(entries); i++ {
+		prev, cur := entries[i-1], entries[i]
+		sb.WriteByte(' ')
+		sb.WriteString(prev.message)
+		sb.WriteString("->")
+		sb.WriteString(cur.message)
+		sb.WriteByte(' ')
+		sb.WriteString(l.at(cur).Sub(l.at(prev)).String())
+	}
+	return l.synth(InfoLevel, sb.String()), true
+}
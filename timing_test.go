@@ -0,0 +1,88 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithStepTimings(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithStepTimings()(logger)
+
+	logger.Debug("a")
+	clock = clock.Add(1200 * time.Microsecond)
+	logger.Debug("b")
+	clock = clock.Add(300 * time.Microsecond)
+	logger.Debug("c")
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] D: a\n[test-123] D: b\n[test-123] D: c\n" +
+		"[test-123] E: test error\n" +
+		"[test-123] I: timings: a->b 1.2ms b->c 300µs\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 10, 4, 5, 123456789, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   &buf,
+	}
+	WithTimeFormat("15:04:05.000")(logger)
+
+	logger.Debug("debug message")
+	clock = clock.Add(time.Second)
+	logger.FlushIf(errors.New("test error"))
+
+	expected := "[test-123] 10:04:05.123 D: debug message\n" +
+		"[test-123] 10:04:06.123 E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWithTimestamps(t *testing.T) {
+	clock := time.Date(2025, 6, 12, 10, 4, 5, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	logger := New(&buf, WithTimestamps())
+	logger.id = "test-123"
+
+	logger.Debug("debug message")
+	logger.Flush()
+
+	if expected := "[test-123] 2025-06-12T10:04:05Z D: debug message\n"; buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	WithTimeFormat("")(logger)
+	logger.Debug("debug message")
+	logger.Flush()
+
+	if expected := "[test-123] D: debug message\n"; buf.String() != expected {
+		t.Errorf("Expected timestamps disabled, got %q", buf.String())
+	}
+}
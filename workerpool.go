@@ -0,0 +1,109 @@
+package failtrace
+
+import (
+	"sync"
+	"time"
+)
+
+// WorkerPool runs asynchronous flushes on a fixed set of goroutines, see
+// WithAsyncFlush. It is safe for concurrent use.
+type WorkerPool struct {
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan func()
+	wg     sync.WaitGroup
+}
+
+// NewWorkerPool starts workers goroutines sharing a queue of up to queue
+// pending flushes. When the queue is full, flushes wait for room. Close stops
+// the workers once the queue is drained.
+func NewWorkerPool(workers, queue int) *WorkerPool {
+	workers = max(workers, 1)
+	p := &WorkerPool{jobs: make(chan func(), max(queue, 0))}
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues job, or runs it in the calling goroutine if p is closed.
+func (p *WorkerPool) submit(job func()) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		job()
+		return
+	}
+	p.jobs <- job
+}
+
+// Close waits for the queued flushes to be written and stops the workers.
+// Flushes handed to a closed pool are written synchronously.
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// WithAsyncFlush makes error flushes return immediately: FlushIf and
+// FlushIfAt with a non-nil error hand a detached copy of the logger to pool,
+// whose workers render and write it, while the logger itself returns to the
+// pool at once. Flush predicates and OnFlush hooks still run on the calling
+// goroutine, and the flush stack and time-dependent lines reflect the moment
+// of the call. Flushes without an error and the other flush methods stay
+// synchronous.
+//
+//	flushes := failtrace.NewWorkerPool(2, 256)
+//	defer flushes.Close()
+//	ctx = failtrace.WithLogger(ctx, failtrace.WithAsyncFlush(flushes))
+func WithAsyncFlush(pool *WorkerPool) Option {
+	return func(l *requestLogger) {
+		l.async = pool
+	}
+}
+
+// flushQueued implements FlushIf and FlushIfAt. With WithAsyncFlush, an error
+// flush hands a detached copy of l to the worker pool and returns l to the
+// pool; otherwise l is flushed in place.
+func (l *requestLogger) flushQueued(level Level, err error) {
+	if err != nil && l.async != nil && !l.flushed && l.shouldFlush != nil && !l.shouldFlush(err) {
+		err = nil
+	}
+	if err == nil || l.async == nil || l.flushed {
+		l.flushIf(level, err, nil)
+		return
+	}
+
+	// Everything depending on the calling goroutine or the current time is
+	// settled here, before the copy leaves it.
+	l.notify(err)
+	d := l.Detach()
+	d.hooks, d.shouldFlush = nil, nil
+	d.clock = fixedClock(l.now())
+	if l.stackDepth > 0 && !l.noErrorLine {
+		d.stack = l.callerStack(level, l.stackDepth)
+		d.stackDepth = 0
+	}
+	l.async.submit(func() {
+		d.flushIf(level, err, nil)
+	})
+	l.put()
+}
+
+// fixedClock always tells the same time, the moment an asynchronous flush was
+// requested.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
@@ -0,0 +1,82 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithAsyncFlush(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := NewWorkerPool(1, 4)
+	ctx := WithLogger(context.Background(), WithWriter(&buf), WithAsyncFlush(flushes))
+	logger := FromContext(ctx)
+	id := logger.ID()
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	if !logger.Flushed() {
+		t.Error("Expected the logger to return to the pool at once")
+	}
+	// Reusing the pooled logger must not affect the queued flush.
+	reused := FromContext(WithLogger(context.Background(), WithWriter(&bytes.Buffer{})))
+	reused.Info("other request")
+	reused.Discard()
+
+	flushes.Close()
+	expected := "[" + id + "] D: debug message\n[" + id + "] E: test error\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWorkerPoolClosed(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := NewWorkerPool(1, 0)
+	flushes.Close()
+
+	logger := New(&buf, WithAsyncFlush(flushes))
+	logger.Info("info message")
+	logger.FlushIf(errors.New("test error"))
+	if !strings.Contains(buf.String(), "I: info message") {
+		t.Errorf("Expected a synchronous flush after Close, got %q", buf.String())
+	}
+}
+
+func TestWithAsyncFlush_SettledOnCaller(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := NewWorkerPool(1, 4)
+	// Hold the worker so the flush below cannot run before the checks.
+	block := make(chan struct{})
+	flushes.submit(func() { <-block })
+
+	clock := &fakeClock{t: time.Date(2025, 6, 12, 10, 0, 0, 0, time.UTC)}
+	ctx, cancel := context.WithDeadline(context.Background(), clock.t.Add(time.Second))
+	defer cancel()
+	hooked := false
+	ctx = WithLogger(ctx, WithWriter(&buf), WithClock(clock), WithAsyncFlush(flushes), WithFlushStack(1),
+		OnFlush(func(FlushInfo) { hooked = true }))
+	logger := FromContext(ctx)
+	logger.Debug("debug message")
+	logger.FlushIf(errors.New("test error"))
+
+	if !hooked {
+		t.Error("Expected the OnFlush hook to run before FlushIf returned")
+	}
+	clock.t = clock.t.Add(time.Hour)
+	close(block)
+	flushes.Close()
+
+	output := buf.String()
+	if !strings.Contains(output, "deadline_remaining=1s") {
+		t.Errorf("Expected the deadline at the time of the call, got %q", output)
+	}
+	if !strings.Contains(output, "TestWithAsyncFlush_SettledOnCaller") {
+		t.Errorf("Expected the stack of the caller, got %q", output)
+	}
+}
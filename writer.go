@@ -0,0 +1,81 @@
+package failtrace
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+)
+
+// BatchWriter is implemented by writers that accept a whole trace at once,
+// such as a client pushing to CloudWatch Logs in a single PutLogEvents call.
+// When the writer of a logger implements it, a flush passes the buffered
+// entries and the flush error, which is nil for Flush, to WriteBatch instead
+// of writing formatted lines.
+type BatchWriter interface {
+	io.Writer
+	WriteBatch(id string, entries []Entry, err error) error
+}
+
+// lineWriter appends every line written to it as an entry of its logger.
+type lineWriter struct {
+	l       *requestLogger
+	level   Level
+	pending []byte
+}
+
+// WriterAt returns a writer that funnels writes into the logger stored in ctx,
+// appending each newline-terminated line as an entry at the given level.
+// A trailing partial line is retained until a later write completes it.
+// Useful for third-party libraries that log to an io.Writer.
+//
+// Usage example:
+//
+//	client.SetLogOutput(failtrace.WriterAt(failtrace.DebugLevel, ctx))
+func WriterAt(level Level, ctx context.Context) io.Writer {
+	return &lineWriter{l: FromContext(ctx), level: level}
+}
+
+// StdLogger returns a standard library logger whose output is appended to the
+// logger stored in ctx at the given level, one entry per line. It has no flags
+// or prefix, so formatting is left to the flush.
+//
+// The returned logger is request-scoped: it must not outlive the request
+// whose context it was created from. Once that logger is flushed it returns
+// to the pool and is reused by another request, so later writes would land
+// in a foreign trace. Do not hand it to long-lived values such as an
+// http.Server. If ctx carries no logger, the output is discarded.
+//
+// Usage example:
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		ctx := failtrace.WithLogger(r.Context())
+//		defer failtrace.FromContext(ctx).FlushIf(nil)
+//		client := legacy.NewClient(legacy.WithLogger(failtrace.StdLogger(ctx, failtrace.DebugLevel)))
+//		...
+//	}
+func StdLogger(ctx context.Context, level Level) *log.Logger {
+	if !HasLogger(ctx) {
+		return log.New(io.Discard, "", 0)
+	}
+	return log.New(WriterAt(level, ctx), "", 0)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		line := p[:i]
+		if len(w.pending) > 0 {
+			line = append(w.pending, line...)
+			w.pending = w.pending[:0]
+		}
+		w.l.log(w.level, string(bytes.TrimSuffix(line, []byte{'\r'})))
+		p = p[i+1:]
+	}
+	w.pending = append(w.pending, p...)
+	return n, nil
+}
@@ -0,0 +1,115 @@
+//go:build !failtrace_disabled
+
+package failtrace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestWriterAt(t *testing.T) {
+	ctx := WithLogger(context.Background())
+	logger := FromContext(ctx)
+	defer logger.FlushIf(nil)
+
+	w := WriterAt(WarnLevel, ctx)
+	if _, err := io.WriteString(w, "first line\nsecond "); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.buf) != 1 {
+		t.Fatalf("Expected partial line to be retained, got %d entries", len(logger.buf))
+	}
+	if _, err := io.WriteString(w, "line\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"first line", "second line"}
+	if len(logger.buf) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(logger.buf))
+	}
+	for i, msg := range expected {
+		if logger.buf[i].level != WarnLevel || logger.buf[i].message != msg {
+			t.Errorf("Entry %d: expected W '%s', got %c '%s'", i, msg, logger.buf[i].level, logger.buf[i].message)
+		}
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	ctx := WithLogger(context.Background())
+	logger := FromContext(ctx)
+	defer logger.FlushIf(nil)
+
+	std := StdLogger(ctx, InfoLevel)
+	std.Printf("listening on %s", ":8080")
+	std.Print("ready")
+
+	expected := []string{"listening on :8080", "ready"}
+	if len(logger.buf) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(logger.buf))
+	}
+	for i, msg := range expected {
+		if logger.buf[i].level != InfoLevel || logger.buf[i].message != msg {
+			t.Errorf("Entry %d: expected I '%s', got %c '%s'", i, msg, logger.buf[i].level, logger.buf[i].message)
+		}
+	}
+}
+
+func TestStdLogger_NoLogger(t *testing.T) {
+	if w := StdLogger(context.Background(), InfoLevel).Writer(); w != io.Discard {
+		t.Errorf("Expected output to be discarded without a logger, got %T", w)
+	}
+}
+
+type mockBatchWriter struct {
+	calls   int
+	id      string
+	entries []Entry
+	err     error
+}
+
+func (m *mockBatchWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("unexpected Write")
+}
+
+func (m *mockBatchWriter) WriteBatch(id string, entries []Entry, err error) error {
+	m.calls++
+	m.id, m.entries, m.err = id, entries, err
+	return nil
+}
+
+func TestBatchWriter(t *testing.T) {
+	w := &mockBatchWriter{}
+	logger := &requestLogger{
+		id:  "test-123",
+		buf: make([]logEntry, 0),
+		w:   w,
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	flushErr := errors.New("test error")
+	if _, err := logger.FlushIfN(flushErr); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.calls != 1 {
+		t.Fatalf("Expected WriteBatch to be called once, got %d", w.calls)
+	}
+	if w.id != "test-123" || w.err != flushErr {
+		t.Errorf("Expected id %q and the flush error, got %q and %v", "test-123", w.id, w.err)
+	}
+	expected := []Entry{
+		{Level: DebugLevel, Message: "debug message"},
+		{Level: InfoLevel, Message: "info message"},
+	}
+	if len(w.entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(w.entries))
+	}
+	for i, e := range expected {
+		if w.entries[i] != e {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, e, w.entries[i])
+		}
+	}
+}